@@ -1,90 +1,177 @@
 package config
 
 import (
+	"fmt"
 	"os"
-	"strconv"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-// Config holds all configuration for the application
+// Config holds all configuration for the application. Values are resolved
+// in order: the defaults below, then config.yaml (if present), then
+// environment variables - each layer overriding the last. Load validates
+// the final result before returning it, so a misconfigured deployment
+// fails at startup rather than on the first request that needs the bad
+// value.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Supabase SupabaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Supabase    SupabaseConfig    `yaml:"supabase"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+
+	// path is the config.yaml this Config was loaded from, kept so Watch
+	// knows what to re-read. It's unexported and so never marshaled.
+	path string
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
-	Env  string
+	Port string `yaml:"port" env:"PORT" validate:"required,port"`
+	Host string `yaml:"host" env:"HOST"`
+	Env  string `yaml:"env" env:"ENV"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `yaml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" env:"DB_USER"`
+	Password string `yaml:"password" env:"DB_PASSWORD"`
+	DBName   string `yaml:"dbname" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE"`
 }
 
 // SupabaseConfig holds Supabase configuration
 type SupabaseConfig struct {
-	URL       string
-	AnonKey   string
-	ServiceKey string
+	URL        string `yaml:"url" env:"SUPABASE_URL" validate:"required,url"`
+	AnonKey    string `yaml:"anon_key" env:"SUPABASE_ANON_KEY" validate:"required"`
+	ServiceKey string `yaml:"service_key" env:"SUPABASE_SERVICE_KEY" validate:"required"`
 }
 
-// JWTConfig holds JWT configuration
+// JWTConfig holds JWT configuration. Secret/ExpiryHours back the legacy
+// HMAC-signed tokens minted by this service; JWKSURL and friends let
+// ValidateToken verify asymmetrically-signed tokens (e.g. Supabase's RS256/
+// ES256 project tokens) instead. JWKSURL is left empty by default so
+// deployments without it keep using the HMAC path unchanged.
 type JWTConfig struct {
-	Secret     string
-	ExpiryHours int
+	Secret      string `yaml:"secret" env:"JWT_SECRET" validate:"required,min=32"`
+	ExpiryHours int    `yaml:"expiry_hours" env:"JWT_EXPIRY_HOURS"`
+
+	JWKSURL    string        `yaml:"jwks_url" env:"JWT_JWKS_URL"`
+	Algorithms []string      `yaml:"algorithms" env:"JWT_ALGORITHMS"`
+	Audience   string        `yaml:"audience" env:"JWT_AUDIENCE"`
+	Issuer     string        `yaml:"issuer" env:"JWT_ISSUER"`
+	CacheTTL   time.Duration `yaml:"jwks_cache_ttl" env:"JWT_JWKS_CACHE_TTL"`
+}
+
+// RateLimitConfig controls RateLimitMiddleware. Backend selects the bucket
+// store: "memory" keeps buckets in the process (fine for a single
+// instance), "redis" shares them across every instance behind the same
+// Redis so a client can't dodge the limit by landing on a different pod.
+type RateLimitConfig struct {
+	Backend       string `yaml:"backend" env:"RATE_LIMIT_BACKEND" validate:"required"`
+	RedisAddr     string `yaml:"redis_addr" env:"RATE_LIMIT_REDIS_ADDR"`
+	RedisPassword string `yaml:"redis_password" env:"RATE_LIMIT_REDIS_PASSWORD"`
+	RedisDB       int    `yaml:"redis_db" env:"RATE_LIMIT_REDIS_DB"`
+}
+
+// IdempotencyConfig controls the Redis-backed store behind
+// routers.IdempotencyMiddleware: cached responses are keyed by
+// (user, Idempotency-Key) and expire after TTL.
+type IdempotencyConfig struct {
+	RedisAddr     string        `yaml:"redis_addr" env:"IDEMPOTENCY_REDIS_ADDR"`
+	RedisPassword string        `yaml:"redis_password" env:"IDEMPOTENCY_REDIS_PASSWORD"`
+	RedisDB       int           `yaml:"redis_db" env:"IDEMPOTENCY_REDIS_DB"`
+	TTL           time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
 }
 
-// Load loads configuration from environment variables
-func Load() *Config {
+// Load builds a Config from defaults, config.yaml (path overridable via
+// CONFIG_PATH, defaulting to "config.yaml" in the working directory) and
+// environment variables, in that order, then validates the result.
+func Load() (*Config, error) {
+	path := getEnv("CONFIG_PATH", "config.yaml")
+
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case os.IsNotExist(err):
+		// No config.yaml is fine - defaults and env vars still apply.
+	default:
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	cfg.path = path
+
+	applyEnvOverrides(reflect.ValueOf(cfg).Elem())
+
+	if err := validateStruct(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func defaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "9000"),
-			Host: getEnv("HOST", "localhost"),
-			Env:  getEnv("ENV", "development"),
+			Port: "9000",
+			Host: "localhost",
+			Env:  "development",
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvAsInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "tradistry"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
-		},
-		Supabase: SupabaseConfig{
-			URL:        getEnv("SUPABASE_URL", ""),
-			AnonKey:    getEnv("SUPABASE_ANON_KEY", ""),
-			ServiceKey: getEnv("SUPABASE_SERVICE_KEY", ""),
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			DBName:  "tradistry",
+			SSLMode: "disable",
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", "your-secret-key"),
-			ExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+			ExpiryHours: 24,
+			Algorithms:  []string{"RS256"},
+			CacheTTL:    10 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Backend: "memory",
+		},
+		Idempotency: IdempotencyConfig{
+			RedisAddr: "localhost:6379",
+			TTL:       24 * time.Hour,
 		},
 	}
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Redact returns a copy of c with secret-bearing fields masked, safe to
+// pass to a logger.
+func (c *Config) Redact() *Config {
+	redacted := *c
+	redacted.Database.Password = mask(c.Database.Password)
+	redacted.Supabase.AnonKey = mask(c.Supabase.AnonKey)
+	redacted.Supabase.ServiceKey = mask(c.Supabase.ServiceKey)
+	redacted.JWT.Secret = mask(c.JWT.Secret)
+	redacted.RateLimit.RedisPassword = mask(c.RateLimit.RedisPassword)
+	redacted.Idempotency.RedisPassword = mask(c.Idempotency.RedisPassword)
+	return &redacted
+}
+
+func mask(s string) string {
+	if s == "" {
+		return s
 	}
-	return defaultValue
+	return "***redacted***"
 }
 
-// getEnvAsInt gets an environment variable as integer or returns a default value
-func getEnvAsInt(key string, defaultValue int) int {
+// getEnv gets an environment variable or returns a default value
+func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+		return value
 	}
 	return defaultValue
 }
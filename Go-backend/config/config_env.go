@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretsDir is where file-based Docker/Kubernetes secrets are mounted.
+const secretsDir = "/run/secrets"
+
+// secretRefPattern matches a "${secret:name}" env var value, which is
+// resolved against a file under secretsDir instead of being used literally.
+var secretRefPattern = regexp.MustCompile(`^\$\{secret:([\w.-]+)\}$`)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnvOverrides walks v's fields, setting any with an `env` tag from the
+// matching environment variable when one is set. It recurses into nested
+// structs (everything in Config except time.Duration, which is itself a
+// struct-shaped int64 and is set directly).
+func applyEnvOverrides(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && field.Type != durationType {
+			applyEnvOverrides(fv)
+			continue
+		}
+
+		envKey := field.Tag.Get("env")
+		if envKey == "" {
+			continue
+		}
+		raw := os.Getenv(envKey)
+		if raw == "" {
+			continue
+		}
+		setFieldFromString(fv, resolveSecretRef(raw))
+	}
+}
+
+// setFieldFromString assigns raw into fv, converting to fv's type. Unknown
+// or unparsable combinations are left at their current value rather than
+// erroring, so a typo'd env var falls back to the file/default layer
+// instead of crashing the loader.
+func setFieldFromString(fv reflect.Value, raw string) {
+	switch {
+	case fv.Type() == durationType:
+		if d, err := time.ParseDuration(raw); err == nil {
+			fv.Set(reflect.ValueOf(d))
+		}
+	case fv.Kind() == reflect.String:
+		fv.SetString(raw)
+	case fv.Kind() == reflect.Int:
+		if n, err := strconv.Atoi(raw); err == nil {
+			fv.SetInt(int64(n))
+		}
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String:
+		fv.Set(reflect.ValueOf(splitAndTrim(raw)))
+	}
+}
+
+func splitAndTrim(raw string) []string {
+	parts := strings.Split(raw, ",")
+	result := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if trimmed := strings.TrimSpace(p); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// resolveSecretRef resolves a "${secret:name}" value against secretsDir.
+// Any other value, or a secret file that can't be read, is returned
+// unchanged - an unresolvable secret reference will fail struct validation
+// downstream rather than silently substituting a wrong value.
+func resolveSecretRef(raw string) string {
+	m := secretRefPattern.FindStringSubmatch(raw)
+	if m == nil {
+		return raw
+	}
+
+	data, err := os.ReadFile(filepath.Join(secretsDir, m[1]))
+	if err != nil {
+		return raw
+	}
+	return strings.TrimSpace(string(data))
+}
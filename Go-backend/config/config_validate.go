@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validateStruct walks v's fields and applies each comma-separated rule in
+// its `validate` tag (e.g. `validate:"required,min=32"`), recursing into
+// nested structs. It fails on the first violation.
+func validateStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && field.Type != durationType {
+			if err := validateStruct(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if err := applyRule(field.Name, fv, rule); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyRule(name string, fv reflect.Value, rule string) error {
+	switch {
+	case rule == "required":
+		if fv.IsZero() {
+			return fmt.Errorf("%s is required", name)
+		}
+	case strings.HasPrefix(rule, "min="):
+		n, err := strconv.Atoi(strings.TrimPrefix(rule, "min="))
+		if err != nil {
+			return nil
+		}
+		if fv.Kind() == reflect.String && len(fv.String()) < n {
+			return fmt.Errorf("%s must be at least %d characters", name, n)
+		}
+	case rule == "url":
+		if fv.Kind() == reflect.String && fv.String() != "" {
+			if _, err := url.ParseRequestURI(fv.String()); err != nil {
+				return fmt.Errorf("%s must be a valid URL: %w", name, err)
+			}
+		}
+	case rule == "port":
+		if fv.Kind() == reflect.String {
+			p, err := strconv.Atoi(fv.String())
+			if err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("%s must be a valid port number (1-65535)", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch re-reads c's backing config.yaml whenever it changes on disk and
+// invokes onChange with the freshly loaded, validated snapshot - letting
+// long-running handlers (e.g. an API key dependent on a rotated Supabase
+// key) pick up new values without a restart. A reload that fails
+// validation is dropped; the process keeps running on the last good
+// config rather than tearing itself down. Watch returns once the watcher
+// is established; the refresh loop itself runs in its own goroutine until
+// ctx is cancelled.
+func (c *Config) Watch(ctx context.Context, onChange func(*Config)) error {
+	if c.path == "" {
+		return fmt.Errorf("config has no backing file to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(c.path) {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				reloaded, err := Load()
+				if err != nil {
+					continue
+				}
+				onChange(reloaded)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
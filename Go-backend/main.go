@@ -3,14 +3,17 @@ package main
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/Johnson-f/tradistry_backend/config"
+	"github.com/Johnson-f/tradistry_backend/middleware"
 	"github.com/Johnson-f/tradistry_backend/routers"
 	"github.com/Johnson-f/tradistry_backend/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -20,7 +23,11 @@ func main() {
 	}
 
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	log.Printf("Configuration loaded: %+v", cfg.Redact())
 
 	// Create a new Fiber instance
 	app := fiber.New(fiber.Config{
@@ -51,9 +58,28 @@ func main() {
 	userService := services.NewSupabaseUserService(dbService)
 	tradeService := services.NewSupabaseTradeService(dbService)
 
+	authService, err := services.NewAuthService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize auth service: %v", err)
+	}
+	go authService.StartJWKSRefresh(ctx)
+
 	// Initialize routers
-	userRouter := routers.NewUserRouter(userService)
-	tradeRouter := routers.NewTradeRouter(tradeService)
+	limiter := middleware.NewRateLimiter(cfg)
+	activitySource := services.NewSupabaseActivitySource(dbService)
+	reconciler := services.NewTradeReconciler(dbService, tradeService, activitySource, services.CostModelFIFO)
+	go reconciler.RunLoop(ctx, 15*time.Minute)
+	profitFixer := services.NewProfitFixer(dbService)
+	klineProvider := services.NewSupabaseKlineProvider(dbService)
+	backtestEngine := services.NewBacktestEngine(dbService, klineProvider)
+	idempotencyRC := redis.NewClient(&redis.Options{
+		Addr:     cfg.Idempotency.RedisAddr,
+		Password: cfg.Idempotency.RedisPassword,
+		DB:       cfg.Idempotency.RedisDB,
+	})
+	userRouter := routers.NewUserRouter(userService, authService)
+	tradeRouter := routers.NewTradeRouter(tradeService, limiter, dbService, reconciler, profitFixer, backtestEngine, idempotencyRC, cfg.Idempotency.TTL)
+	authRouter := routers.NewAuthRouter(authService, userService)
 
 	// Basic routes
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -76,6 +102,7 @@ func main() {
 	// Setup routes
 	userRouter.SetupUserRoutes(api)
 	tradeRouter.SetupTradeRoutes(api)
+	authRouter.SetupAuthRoutes(api)
 
 	// Example API endpoint
 	api.Get("/ping", func(c *fiber.Ctx) error {
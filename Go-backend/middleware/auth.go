@@ -12,7 +12,7 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get the Authorization header
 		authHeader := c.Get("Authorization")
-		
+
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Authorization header required",
@@ -28,7 +28,7 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 
 		// Extract token (remove "Bearer " prefix)
 		token := strings.TrimPrefix(authHeader, "Bearer ")
-		
+
 		if token == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Token required",
@@ -48,24 +48,87 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 		c.Locals("userID", user.ID)
 		c.Locals("userEmail", user.Email)
 		c.Locals("userRole", user.Role)
-		
+		c.Locals("userAppMetadata", user.AppMetadata)
+
+		return c.Next()
+	}
+}
+
+// FlexibleAuthMiddleware accepts either a Supabase JWT
+// ("Authorization: Bearer <jwt>") or an API key
+// ("Authorization: ApiKey <keyid.secret>"), so user-owned automation can
+// call the same routes a logged-in user would without holding a JWT.
+func FlexibleAuthMiddleware(authService *services.AuthService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		authHeader := c.Get("Authorization")
+
+		if authHeader == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Authorization header required",
+			})
+		}
+
+		var user *services.AuthUser
+		var err error
+
+		switch {
+		case strings.HasPrefix(authHeader, "Bearer "):
+			user, err = authService.ValidateToken(strings.TrimPrefix(authHeader, "Bearer "))
+		case strings.HasPrefix(authHeader, "ApiKey "):
+			user, err = authService.ValidateAPIKey(strings.TrimPrefix(authHeader, "ApiKey "))
+		default:
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid authorization format. Use Bearer or ApiKey",
+			})
+		}
+
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired credentials",
+			})
+		}
+
+		c.Locals("user", user)
+		c.Locals("userID", user.ID)
+		c.Locals("userEmail", user.Email)
+		c.Locals("userRole", user.Role)
+		c.Locals("userScopes", user.Scopes)
+		c.Locals("userAppMetadata", user.AppMetadata)
+
 		return c.Next()
 	}
 }
 
-// OptionalAuthMiddleware handles optional authentication
-func OptionalAuthMiddleware() fiber.Handler {
+// OptionalAuthMiddleware validates the bearer token when one is present but
+// lets the request through either way, so handlers can tell an anonymous
+// caller apart from an authenticated one by checking whether "userID" is
+// set in c.Locals rather than trusting a hardcoded placeholder user.
+func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
-		
-		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-			token := strings.TrimPrefix(authHeader, "Bearer ")
-			if token != "" {
-				// Store user ID in context if token is present
-				c.Locals("userID", 1)
-			}
+
+		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+			return c.Next()
 		}
-		
+
+		token := strings.TrimPrefix(authHeader, "Bearer ")
+		if token == "" {
+			return c.Next()
+		}
+
+		user, err := authService.ValidateToken(token)
+		if err != nil {
+			// An invalid token on an optional route is treated as
+			// anonymous rather than rejected outright.
+			return c.Next()
+		}
+
+		c.Locals("user", user)
+		c.Locals("userID", user.ID)
+		c.Locals("userEmail", user.Email)
+		c.Locals("userRole", user.Role)
+		c.Locals("userAppMetadata", user.AppMetadata)
+
 		return c.Next()
 	}
 }
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitRule describes a token bucket: Limit tokens are available per
+// Window, refilling continuously. Name namespaces the bucket key so
+// multiple rules can share one limiter instance without colliding.
+type RateLimitRule struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimiter is the interface RateLimitMiddleware drives. It's backed by
+// either an in-process bucket (NewRateLimiter with Backend "memory") or a
+// Redis-backed one shared across instances (Backend "redis").
+type RateLimiter interface {
+	// Allow reports whether a request against key under rule is permitted,
+	// along with the tokens remaining and, if denied, how long the caller
+	// should wait before retrying.
+	Allow(ctx context.Context, key string, rule RateLimitRule) (allowed bool, remaining int, retryAfter time.Duration, err error)
+}
+
+// NewRateLimiter builds the RateLimiter selected by cfg.RateLimit.Backend.
+func NewRateLimiter(cfg *config.Config) RateLimiter {
+	if cfg.RateLimit.Backend == "redis" {
+		return newRedisLimiter(cfg)
+	}
+	return newInProcessLimiter()
+}
+
+// RateLimitMiddleware enforces rule against limiter, keyed by the
+// authenticated user when AuthMiddleware/FlexibleAuthMiddleware ran first,
+// falling back to the caller's IP otherwise. It fails open on a limiter
+// error (e.g. Redis unreachable) rather than blocking every request behind
+// a down dependency.
+func RateLimitMiddleware(limiter RateLimiter, rule RateLimitRule) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := rateLimitKey(c)
+
+		allowed, remaining, retryAfter, err := limiter.Allow(c.Context(), key, rule)
+		if err != nil {
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", remaining))
+
+		if !allowed {
+			c.Set("Retry-After", fmt.Sprintf("%d", int(retryAfter.Seconds())+1))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Rate limit exceeded",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// rateLimitKey derives the bucket key for c: the authenticated user id when
+// one of the auth middlewares set it, otherwise the caller's IP.
+func rateLimitKey(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
+
+// bucketKey namespaces rule.Name into key so one limiter instance can serve
+// several rules without their buckets colliding.
+func bucketKey(key string, rule RateLimitRule) string {
+	return rule.Name + ":" + key
+}
+
+// inProcessLimiter is a sync.Map-based token bucket, good for a single
+// instance or as the default when no shared store is configured. Buckets
+// refill lazily (on the next Allow call) rather than via a background
+// goroutine, so an idle bucket costs nothing until it's used again.
+type inProcessLimiter struct {
+	buckets sync.Map // string -> *tokenBucket
+}
+
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newInProcessLimiter() *inProcessLimiter {
+	return &inProcessLimiter{}
+}
+
+func (l *inProcessLimiter) Allow(_ context.Context, key string, rule RateLimitRule) (bool, int, time.Duration, error) {
+	k := bucketKey(key, rule)
+	v, _ := l.buckets.LoadOrStore(k, &tokenBucket{
+		tokens:     float64(rule.Limit),
+		lastRefill: time.Now(),
+	})
+	b := v.(*tokenBucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill)
+	refillRate := float64(rule.Limit) / rule.Window.Seconds()
+	b.tokens += elapsed.Seconds() * refillRate
+	if b.tokens > float64(rule.Limit) {
+		b.tokens = float64(rule.Limit)
+	}
+	b.lastRefill = now
+
+	if b.tokens < 1 {
+		missing := 1 - b.tokens
+		retryAfter := time.Duration(missing/refillRate) * time.Second
+		return false, 0, retryAfter, nil
+	}
+
+	b.tokens--
+	return true, int(b.tokens), 0, nil
+}
+
+// redisLimiter implements the same token bucket algorithm as
+// inProcessLimiter but keeps the state in Redis, via a Lua script so the
+// read-refill-decrement sequence is atomic across concurrent instances.
+type redisLimiter struct {
+	client *redis.Client
+}
+
+func newRedisLimiter(cfg *config.Config) *redisLimiter {
+	return &redisLimiter{
+		client: redis.NewClient(&redis.Options{
+			Addr:     cfg.RateLimit.RedisAddr,
+			Password: cfg.RateLimit.RedisPassword,
+			DB:       cfg.RateLimit.RedisDB,
+		}),
+	}
+}
+
+// tokenBucketScript mirrors inProcessLimiter's algorithm: refill by elapsed
+// time since the last request, then take one token if available. It stores
+// tokens and the last-refill timestamp as a Redis hash with a TTL so an
+// abandoned bucket is eventually cleaned up.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", key, "tokens", "refilled_at")
+local tokens = tonumber(data[1])
+local refilledAt = tonumber(data[2])
+
+if tokens == nil then
+	tokens = limit
+	refilledAt = now
+end
+
+local rate = limit / window
+local elapsed = math.max(0, now - refilledAt)
+tokens = math.min(limit, tokens + elapsed * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - 1
+end
+
+redis.call("HMSET", key, "tokens", tokens, "refilled_at", now)
+redis.call("EXPIRE", key, math.ceil(window) * 2)
+
+-- tokens is fractional (partial refills between requests); Redis converts a
+-- bare Lua number reply to an integer, truncating it, so it's stringified
+-- here and parsed back as a float on the Go side.
+return {allowed, tostring(tokens)}
+`)
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule RateLimitRule) (bool, int, time.Duration, error) {
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+
+	res, err := tokenBucketScript.Run(ctx, l.client, []string{bucketKey(key, rule)},
+		rule.Limit, rule.Window.Seconds(), now).Result()
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("rate limit: redis eval: %w", err)
+	}
+
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 2 {
+		return false, 0, 0, fmt.Errorf("rate limit: unexpected redis script result")
+	}
+
+	allowed, _ := vals[0].(int64)
+	tokens, _ := vals[1].(string)
+	var tokensF float64
+	fmt.Sscanf(tokens, "%f", &tokensF)
+	remaining := int(tokensF)
+
+	if allowed == 1 {
+		return true, remaining, 0, nil
+	}
+
+	refillRate := float64(rule.Limit) / rule.Window.Seconds()
+	retryAfter := time.Duration((1.0 / refillRate) * float64(time.Second))
+	return false, 0, retryAfter, nil
+}
@@ -12,12 +12,3 @@ func ValidationMiddleware() fiber.Handler {
 		return c.Next()
 	}
 }
-
-// RateLimitMiddleware handles rate limiting
-func RateLimitMiddleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Add rate limiting logic here
-		// For now, just continue to next handler
-		return c.Next()
-	}
-}
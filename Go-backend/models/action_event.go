@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ActionEvent is a single entry in a user's security/activity timeline -
+// e.g. a login, a profile update, or a SnapTrade account sync.
+type ActionEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	AccountID int64     `json:"account_id" db:"account_id"`
+	Action    string    `json:"action" db:"action"`
+	Target    string    `json:"target,omitempty" db:"target"`
+	IP        string    `json:"ip" db:"ip"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
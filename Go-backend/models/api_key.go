@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ApiKey is a long-lived credential an account can mint for programmatic
+// access, in lieu of a short-lived Supabase JWT. Only HashedSecret is ever
+// persisted - the raw secret is returned once, at creation time.
+type ApiKey struct {
+	ID           string     `json:"id" db:"id"`
+	AccountID    int64      `json:"account_id" db:"account_id"`
+	Name         string     `json:"name" db:"name"`
+	Description  string     `json:"description,omitempty" db:"description"`
+	HashedSecret string     `json:"-" db:"hashed_secret"`
+	Scopes       []string   `json:"scopes" db:"scopes"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
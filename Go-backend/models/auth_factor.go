@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// FactorType identifies the kind of credential an AuthFactor verifies.
+type FactorType string
+
+const (
+	FactorTOTP       FactorType = "totp"
+	FactorEmailOTP   FactorType = "email_otp"
+	FactorBackupCode FactorType = "backup_code"
+)
+
+// AuthFactor is a single enrolled MFA credential for a user. Secret holds
+// whatever material that factor type needs to verify a response: a base32
+// TOTP seed, a hashed one-time email code, or a hashed backup code.
+type AuthFactor struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	Type      FactorType `json:"type" db:"type"`
+	Label     string     `json:"label" db:"label"`
+	Secret    string     `json:"-" db:"secret"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Challenge is a pending MFA challenge issued by AuthService.StartChallenge.
+// It is keyed by ID plus the IP+User-Agent Fingerprint it was issued to, so a
+// stolen challenge ID alone isn't enough to attempt verification.
+type Challenge struct {
+	ID                string    `json:"id" db:"id"`
+	UserID            int64     `json:"user_id" db:"user_id"`
+	Fingerprint       string    `json:"-" db:"fingerprint"`
+	RemainingAttempts int       `json:"-" db:"remaining_attempts"`
+	Fulfilled         bool      `json:"-" db:"fulfilled"`
+	ExpiresAt         time.Time `json:"-" db:"expires_at"`
+	CreatedAt         time.Time `json:"-" db:"created_at"`
+}
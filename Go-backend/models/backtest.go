@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// BacktestStrategy names the exit rule a backtest run replays against
+// historical klines.
+type BacktestStrategy string
+
+const (
+	// BacktestATRPin exits when price closes back through entry +/- ATR *
+	// ATRMultiple (a volatility-scaled stop/target, recomputed each bar).
+	BacktestATRPin BacktestStrategy = "atr_pin"
+	// BacktestTrailingStop exits when price retraces TrailingStopPct from
+	// the best close seen since entry.
+	BacktestTrailingStop BacktestStrategy = "trailing_stop"
+	// BacktestFixedR exits at a fixed multiple of the trade's own
+	// stop-distance (entry price - stop loss) as the target, the stop loss
+	// itself as the loss side.
+	BacktestFixedR BacktestStrategy = "fixed_r"
+)
+
+// BacktestConfig describes the strategy and window a backtest run replays
+// actual closed trades against. It's accepted either as a JSON body or as
+// a YAML config upload (see BacktestConfigFromYAML).
+type BacktestConfig struct {
+	Strategy BacktestStrategy `json:"strategy" yaml:"strategy" validate:"required,oneof=atr_pin trailing_stop fixed_r"`
+
+	// ATRPeriod/ATRMultiple parameterize BacktestATRPin.
+	ATRPeriod   int     `json:"atr_period,omitempty" yaml:"atr_period,omitempty"`
+	ATRMultiple float64 `json:"atr_multiple,omitempty" yaml:"atr_multiple,omitempty"`
+
+	// TrailingStopPct parameterizes BacktestTrailingStop, e.g. 0.05 for a
+	// 5% trailing stop.
+	TrailingStopPct float64 `json:"trailing_stop_pct,omitempty" yaml:"trailing_stop_pct,omitempty"`
+
+	// FixedRMultiple parameterizes BacktestFixedR: the target distance as
+	// a multiple of the trade's own entry-to-stop distance.
+	FixedRMultiple float64 `json:"fixed_r_multiple,omitempty" yaml:"fixed_r_multiple,omitempty"`
+
+	StartTime time.Time `json:"start_time" yaml:"start_time" validate:"required"`
+	EndTime   time.Time `json:"end_time" yaml:"end_time" validate:"required"`
+
+	// Symbols restricts the replay to a subset of the user's closed
+	// trades; empty means every closed trade in the window.
+	Symbols []string `json:"symbols,omitempty" yaml:"symbols,omitempty"`
+}
+
+// Kline is a single OHLCV bar, as returned by a KlineProvider.
+type Kline struct {
+	Timestamp time.Time `json:"timestamp"`
+	Open      float64   `json:"open"`
+	High      float64   `json:"high"`
+	Low       float64   `json:"low"`
+	Close     float64   `json:"close"`
+	Volume    float64   `json:"volume"`
+}
+
+// EquityPoint is one sample of a backtest's cumulative hypothetical P&L,
+// taken at each simulated trade's exit.
+type EquityPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Equity    float64   `json:"equity"`
+}
+
+// TradeAttribution compares one closed Trade's actual outcome against the
+// strategy's simulated replay of the same trade.
+type TradeAttribution struct {
+	TradeID        int64     `json:"trade_id"`
+	Symbol         string    `json:"symbol"`
+	EntryDate      time.Time `json:"entry_date"`
+	ActualExitDate time.Time `json:"actual_exit_date,omitempty"`
+	ActualPnL      float64   `json:"actual_pnl"`
+	SimulatedExit  time.Time `json:"simulated_exit,omitempty"`
+	SimulatedPrice float64   `json:"simulated_price,omitempty"`
+	SimulatedPnL   float64   `json:"simulated_pnl"`
+	Delta          float64   `json:"delta"`
+	Skipped        bool      `json:"skipped,omitempty"`
+	SkippedReason  string    `json:"skipped_reason,omitempty"`
+}
+
+// BacktestReport is the output of replaying a BacktestConfig against a
+// user's closed trades.
+type BacktestReport struct {
+	TradeCount   int                `json:"trade_count"`
+	ActualPnL    float64            `json:"actual_pnl"`
+	SimulatedPnL float64            `json:"simulated_pnl"`
+	MaxDrawdown  float64            `json:"max_drawdown"`
+	SharpeRatio  float64            `json:"sharpe_ratio"`
+	SortinoRatio float64            `json:"sortino_ratio"`
+	EquityCurve  []EquityPoint      `json:"equity_curve"`
+	Attributions []TradeAttribution `json:"attributions"`
+}
+
+// BacktestRun is a persisted backtest_runs row: the config it was run
+// with plus the resulting report, so past runs can be listed and diffed
+// without re-running the replay.
+type BacktestRun struct {
+	ID        int64          `json:"id" db:"id"`
+	UserID    int64          `json:"user_id" db:"user_id"`
+	Config    BacktestConfig `json:"config" db:"config"`
+	Report    BacktestReport `json:"report" db:"report"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// BacktestRunRequest is the payload for POST /trades/backtest.
+type BacktestRunRequest struct {
+	Config BacktestConfig `json:"config"`
+}
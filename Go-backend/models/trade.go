@@ -22,26 +22,31 @@ const (
 
 // Trade represents a trading transaction
 type Trade struct {
-	ID          int64       `json:"id" db:"id"`
-	UserID      int64       `json:"user_id" db:"user_id"`
-	Symbol      string      `json:"symbol" db:"symbol"`
-	Type        TradeType   `json:"type" db:"type"`
-	Status      TradeStatus `json:"status" db:"status"`
-	Quantity    float64     `json:"quantity" db:"quantity"`
-	EntryPrice  float64     `json:"entry_price" db:"entry_price"`
-	ExitPrice   *float64    `json:"exit_price,omitempty" db:"exit_price"`
-	StopLoss    *float64    `json:"stop_loss,omitempty" db:"stop_loss"`
-	TakeProfit  *float64    `json:"take_profit,omitempty" db:"take_profit"`
-	PnL         *float64    `json:"pnl,omitempty" db:"pnl"`
-	Notes       string      `json:"notes" db:"notes"`
-	EntryDate   time.Time   `json:"entry_date" db:"entry_date"`
-	ExitDate    *time.Time  `json:"exit_date,omitempty" db:"exit_date"`
-	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+	ID         int64       `json:"id" db:"id"`
+	UserID     int64       `json:"user_id" db:"user_id"`
+	Symbol     string      `json:"symbol" db:"symbol"`
+	Type       TradeType   `json:"type" db:"type"`
+	Status     TradeStatus `json:"status" db:"status"`
+	Quantity   float64     `json:"quantity" db:"quantity"`
+	EntryPrice float64     `json:"entry_price" db:"entry_price"`
+	ExitPrice  *float64    `json:"exit_price,omitempty" db:"exit_price"`
+	StopLoss   *float64    `json:"stop_loss,omitempty" db:"stop_loss"`
+	TakeProfit *float64    `json:"take_profit,omitempty" db:"take_profit"`
+	PnL        *float64    `json:"pnl,omitempty" db:"pnl"`
+	Notes      string      `json:"notes" db:"notes"`
+	EntryDate  time.Time   `json:"entry_date" db:"entry_date"`
+	ExitDate   *time.Time  `json:"exit_date,omitempty" db:"exit_date"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at" db:"updated_at"`
 }
 
 // TradeCreateRequest represents the request payload for creating a trade
 type TradeCreateRequest struct {
+	// ClientID optionally lets the caller supply its own identifier for a
+	// row (e.g. the broker's fill id) so a bulk import can report
+	// rejections against the caller's own reference rather than a
+	// positional index alone.
+	ClientID   string    `json:"client_id,omitempty"`
 	Symbol     string    `json:"symbol" validate:"required"`
 	Type       TradeType `json:"type" validate:"required,oneof=buy sell"`
 	Quantity   float64   `json:"quantity" validate:"required,gt=0"`
@@ -52,12 +57,77 @@ type TradeCreateRequest struct {
 	EntryDate  time.Time `json:"entry_date" validate:"required"`
 }
 
+// TradeBulkImportRequest is the payload for POST /trades/bulk: a batch of
+// fills already known to an external, authoritative ledger (e.g. a broker
+// export), imported as a single gateway operation instead of one
+// CreateTrade call per row.
+type TradeBulkImportRequest struct {
+	Trades []TradeCreateRequest `json:"trades" validate:"required,min=1"`
+}
+
+// TradeBulkImportRejection records why a single row in a bulk import was
+// skipped.
+type TradeBulkImportRejection struct {
+	Index    int    `json:"index"`
+	ClientID string `json:"client_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// TradeBulkImportResult is the response for POST /trades/bulk: a count and
+// any rejections. No PnL is recomputed and no rows are re-selected - the
+// caller already has the authoritative record.
+type TradeBulkImportResult struct {
+	Inserted int                        `json:"inserted"`
+	Rejected []TradeBulkImportRejection `json:"rejected"`
+}
+
 // TradeUpdateRequest represents the request payload for updating a trade
 type TradeUpdateRequest struct {
-	ExitPrice  *float64   `json:"exit_price" validate:"omitempty,gt=0"`
-	StopLoss   *float64   `json:"stop_loss" validate:"omitempty,gt=0"`
-	TakeProfit *float64   `json:"take_profit" validate:"omitempty,gt=0"`
-	Notes      string     `json:"notes"`
-	ExitDate   *time.Time `json:"exit_date"`
+	ExitPrice  *float64    `json:"exit_price" validate:"omitempty,gt=0"`
+	StopLoss   *float64    `json:"stop_loss" validate:"omitempty,gt=0"`
+	TakeProfit *float64    `json:"take_profit" validate:"omitempty,gt=0"`
+	Notes      string      `json:"notes"`
+	ExitDate   *time.Time  `json:"exit_date"`
 	Status     TradeStatus `json:"status" validate:"omitempty,oneof=open closed"`
 }
+
+// AuditTrade is a row in the audit_trades table: a record of one
+// reconciliation event applied to a Trade, kept for traceability
+// independent of the Trade row itself (which may later be updated again).
+type AuditTrade struct {
+	ID             int64     `json:"id" db:"id"`
+	TradeID        int64     `json:"trade_id" db:"trade_id"`
+	UserID         int64     `json:"user_id" db:"user_id"`
+	Action         string    `json:"action" db:"action"`
+	FillExternalID string    `json:"fill_external_id" db:"fill_external_id"`
+	MatchedQty     float64   `json:"matched_qty" db:"matched_qty"`
+	ExitPrice      float64   `json:"exit_price" db:"exit_price"`
+	PnL            float64   `json:"pnl" db:"pnl"`
+	CostModel      string    `json:"cost_model" db:"cost_model"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// TradeListQuery is the parsed form of GetTradesHandler's query string:
+// ?symbol=&status=&type=&from=&to=&min_pnl=&max_pnl=&sort=exit_date:desc&limit=&cursor=.
+// Pointer fields are only applied when the caller supplied them.
+type TradeListQuery struct {
+	Symbol     string
+	Status     TradeStatus
+	Type       TradeType
+	From       *time.Time
+	To         *time.Time
+	MinPnL     *float64
+	MaxPnL     *float64
+	SortColumn string
+	SortDesc   bool
+	Limit      int
+	Cursor     string
+}
+
+// TradeListResult is the response for GET /trades: a page of rows plus the
+// cursor to pass back as ?cursor= for the next page (empty when this was
+// the last page).
+type TradeListResult struct {
+	Data       []Trade `json:"data"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
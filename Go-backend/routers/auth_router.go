@@ -0,0 +1,124 @@
+package routers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Johnson-f/tradistry_backend/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthRouter handles the MFA challenge/response endpoints.
+type AuthRouter struct {
+	authService *services.AuthService
+	userService *services.SupabaseUserService
+}
+
+// NewAuthRouter creates a new auth router instance
+func NewAuthRouter(authService *services.AuthService, userService *services.SupabaseUserService) *AuthRouter {
+	return &AuthRouter{
+		authService: authService,
+		userService: userService,
+	}
+}
+
+// SetupAuthRoutes configures the MFA challenge/response routes
+func (ar *AuthRouter) SetupAuthRoutes(api fiber.Router) {
+	auth := api.Group("/auth")
+
+	auth.Post("/challenge/start", ar.StartChallenge)
+	auth.Post("/challenge/verify", ar.VerifyChallenge)
+	auth.Get("/userinfo", ar.UserInfo)
+}
+
+type startChallengeRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+}
+
+// StartChallenge enumerates the caller's enrolled factors, persists a
+// Challenge fingerprinted to the requester's IP+User-Agent, and returns the
+// challenge id plus the available factor list.
+func (ar *AuthRouter) StartChallenge(c *fiber.Ctx) error {
+	var req startChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	factors, err := ar.userService.ListFactors(c.Context(), req.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to resolve enrolled factors",
+		})
+	}
+
+	challenge, available, err := ar.authService.StartChallenge(c.Context(), req.UserID, c.IP(), c.Get("User-Agent"), factors)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start challenge",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"challenge_id": challenge.ID,
+		"factors":      available,
+	})
+}
+
+type verifyChallengeRequest struct {
+	ChallengeID string `json:"challenge_id" validate:"required"`
+	UserID      int64  `json:"user_id" validate:"required"`
+	FactorID    string `json:"factor_id" validate:"required"`
+	Secret      string `json:"secret" validate:"required"`
+}
+
+// VerifyChallenge validates the submitted factor response and, on success,
+// mints a JWT for the challenged user.
+func (ar *AuthRouter) VerifyChallenge(c *fiber.Ctx) error {
+	var req verifyChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	token, err := ar.authService.VerifyChallenge(c.Context(), req.ChallengeID, c.IP(), c.Get("User-Agent"), req.FactorID, req.Secret, req.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"access_token": token,
+	})
+}
+
+// UserInfo implements an OIDC-compatible /userinfo endpoint: it validates
+// the bearer token, joins the user row, and returns the standard OIDC
+// claim set instead of the ad-hoc UserResponse shape.
+func (ar *AuthRouter) UserInfo(c *fiber.Ctx) error {
+	authUser, err := ar.authService.ValidateToken(c.Get("Authorization"))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired token",
+		})
+	}
+
+	userID, err := strconv.ParseInt(strings.TrimSpace(authUser.ID), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Token subject is not a valid user id",
+		})
+	}
+
+	user, err := ar.userService.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	return c.JSON(services.UserInfoClaims(*user))
+}
@@ -0,0 +1,208 @@
+package routers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// idempotencyKeyPrefix namespaces every key this middleware writes, so
+// PurgeHandler can find them all with a single SCAN and so the keyspace
+// can't collide with the rate limiter's buckets on the same Redis.
+const idempotencyKeyPrefix = "idempotency:"
+
+// idempotencyClaimTTL bounds how long a key stays reserved as "in flight"
+// before another request is allowed to retry it - comfortably longer than
+// any real handler should take, so a crashed request doesn't wedge the key
+// until the full response TTL expires.
+const idempotencyClaimTTL = 30 * time.Second
+
+// idempotencyRecord is what gets cached in Redis for one (user, method,
+// path, Idempotency-Key): the request's fingerprint, so a reused key with
+// a different request can be rejected, and the response to replay for a
+// genuine retry. InFlight marks a claim that's still being handled, before
+// a response exists to replay.
+type idempotencyRecord struct {
+	RequestHash  string `json:"request_hash"`
+	InFlight     bool   `json:"in_flight,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	ResponseBody string `json:"response_body"`
+}
+
+// IdempotencyMiddleware makes the handlers it wraps safe to retry: a
+// caller that resends the same request with the same Idempotency-Key
+// header gets back the original response instead of re-executing the
+// handler, so a flaky network or a double-click can't double-submit. The
+// fingerprint covers (user, method, path, key, body) rather than just the
+// body, so the same key can't be replayed against a different route.
+// Requests without the header pass through unchanged.
+//
+// The first request for a key atomically claims it (SET NX) before the
+// handler runs, so two concurrent requests with the same key can't both
+// slip past the "no existing record" check and both execute - the loser
+// of the race sees the in-flight claim and is told to back off instead of
+// re-running the handler.
+//
+// A Redis error while claiming or checking a key fails open (the handler
+// runs normally) rather than blocking the request on a down dependency; a
+// Redis error while persisting the final response just means a later
+// retry re-executes the handler too.
+func IdempotencyMiddleware(client *redis.Client, ttl time.Duration) fiber.Handler {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Idempotency-Key")
+		if key == "" {
+			return c.Next()
+		}
+
+		redisKey := idempotencyRedisKey(idempotencyCallerID(c), key)
+		requestHash := hashIdempotencyRequest(idempotencyCallerID(c), c.Method(), c.Path(), key, c.Body())
+
+		claimed, err := claimIdempotencyRecord(c.Context(), client, redisKey, requestHash)
+		if err != nil {
+			return c.Next()
+		}
+
+		if !claimed {
+			existing, err := loadIdempotencyRecord(c.Context(), client, redisKey)
+			if err != nil || existing == nil {
+				return c.Next()
+			}
+			if existing.RequestHash != requestHash {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": "Idempotency key was already used with a different request",
+					"code":  "IDEMPOTENCY_KEY_CONFLICT",
+				})
+			}
+			if existing.InFlight {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this idempotency key is already in progress",
+					"code":  "IDEMPOTENCY_KEY_IN_PROGRESS",
+				})
+			}
+			c.Status(existing.StatusCode)
+			return c.Send([]byte(existing.ResponseBody))
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		record := idempotencyRecord{
+			RequestHash:  requestHash,
+			StatusCode:   c.Response().StatusCode(),
+			ResponseBody: string(c.Response().Body()),
+		}
+		_ = storeIdempotencyRecord(context.Background(), client, redisKey, record, ttl)
+
+		return nil
+	}
+}
+
+// claimIdempotencyRecord atomically reserves redisKey for requestHash via
+// SET NX, so only one of a set of concurrent requests with the same key
+// ever gets claimed == true. The claim is a short-lived in-flight marker;
+// the eventual response overwrites it with the real TTL.
+func claimIdempotencyRecord(ctx context.Context, client *redis.Client, redisKey, requestHash string) (bool, error) {
+	raw, err := json.Marshal(idempotencyRecord{RequestHash: requestHash, InFlight: true})
+	if err != nil {
+		return false, err
+	}
+	return client.SetNX(ctx, redisKey, raw, idempotencyClaimTTL).Result()
+}
+
+// idempotencyCallerID scopes a key to the authenticated user when
+// AuthMiddleware ran first, falling back to the caller's IP so anonymous
+// mutating routes still get protection.
+func idempotencyCallerID(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("userID").(string); ok && userID != "" {
+		return userID
+	}
+	return "ip:" + c.IP()
+}
+
+// hashIdempotencyRequest fingerprints the request so a reused key against
+// a different method, path, or body can be detected and rejected.
+func hashIdempotencyRequest(userID, method, path, key string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(userID))
+	h.Write([]byte{0})
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func idempotencyRedisKey(userID, key string) string {
+	return idempotencyKeyPrefix + userID + ":" + key
+}
+
+func loadIdempotencyRecord(ctx context.Context, client *redis.Client, redisKey string) (*idempotencyRecord, error) {
+	raw, err := client.Get(ctx, redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func storeIdempotencyRecord(ctx context.Context, client *redis.Client, redisKey string, record idempotencyRecord, ttl time.Duration) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return client.Set(ctx, redisKey, raw, ttl).Err()
+}
+
+// PurgeIdempotencyKeysHandler handles POST /trades/idempotency-keys/purge:
+// an admin/test-only route that deletes every cached idempotency response,
+// so an integration test suite can reset between runs without waiting out
+// the TTL.
+func PurgeIdempotencyKeysHandler(client *redis.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+		var (
+			cursor  uint64
+			deleted int
+		)
+
+		for {
+			keys, next, err := client.Scan(ctx, cursor, idempotencyKeyPrefix+"*", 100).Result()
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			}
+			if len(keys) > 0 {
+				if err := client.Del(ctx, keys...).Err(); err != nil {
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+				}
+				deleted += len(keys)
+			}
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+
+		return c.JSON(fiber.Map{"purged": deleted})
+	}
+}
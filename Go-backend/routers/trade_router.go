@@ -1,36 +1,83 @@
 package routers
 
 import (
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/middleware"
 	"github.com/Johnson-f/tradistry_backend/services"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// createTradeRule and listTradesRule bound how often a single caller may
+// hit the write and read paths respectively - writes are limited much
+// harder since each one triggers a database insert.
+var (
+	createTradeRule = middleware.RateLimitRule{Name: "trades:create", Limit: 60, Window: time.Minute}
+	listTradesRule  = middleware.RateLimitRule{Name: "trades:list", Limit: 600, Window: time.Minute}
 )
 
 // TradeRouter handles trade-related routes
 type TradeRouter struct {
-	tradeService services.TradeServiceInterface
+	tradeService   services.TradeServiceInterface
+	limiter        middleware.RateLimiter
+	db             *services.DatabaseService
+	reconciler     *services.TradeReconciler
+	profitFixer    *services.ProfitFixer
+	backtest       *services.BacktestEngine
+	idempotencyRC  *redis.Client
+	idempotencyTTL time.Duration
 }
 
 // NewTradeRouter creates a new trade router instance
-func NewTradeRouter(tradeService services.TradeServiceInterface) *TradeRouter {
+func NewTradeRouter(tradeService services.TradeServiceInterface, limiter middleware.RateLimiter, db *services.DatabaseService, reconciler *services.TradeReconciler, profitFixer *services.ProfitFixer, backtest *services.BacktestEngine, idempotencyRC *redis.Client, idempotencyTTL time.Duration) *TradeRouter {
 	return &TradeRouter{
-		tradeService: tradeService,
+		tradeService:   tradeService,
+		limiter:        limiter,
+		db:             db,
+		reconciler:     reconciler,
+		profitFixer:    profitFixer,
+		backtest:       backtest,
+		idempotencyRC:  idempotencyRC,
+		idempotencyTTL: idempotencyTTL,
 	}
 }
 
 // SetupTradeRoutes configures trade routes
 func (tr *TradeRouter) SetupTradeRoutes(api fiber.Router) {
 	trades := api.Group("/trades")
+	idempotent := IdempotencyMiddleware(tr.idempotencyRC, tr.idempotencyTTL)
 
-	trades.Post("/", tr.CreateTrade)
-	trades.Get("/", tr.GetTrades)
+	trades.Post("/", middleware.RateLimitMiddleware(tr.limiter, createTradeRule), idempotent, tr.CreateTrade)
+	trades.Post("/bulk", middleware.RateLimitMiddleware(tr.limiter, createTradeRule), idempotent, tr.BulkImportTrades)
+	trades.Get("/", middleware.RateLimitMiddleware(tr.limiter, listTradesRule), tr.GetTrades)
 	trades.Get("/:id", tr.GetTrade)
-	trades.Put("/:id", tr.UpdateTrade)
-	trades.Delete("/:id", tr.DeleteTrade)
-	trades.Post("/:id/close", tr.CloseTrade)
-	
+	trades.Put("/:id", idempotent, tr.UpdateTrade)
+	trades.Delete("/:id", idempotent, tr.DeleteTrade)
+	trades.Post("/:id/close", idempotent, tr.CloseTrade)
+
+	// Admin/test-only: clears every cached idempotency response so an
+	// integration suite can reset between runs without waiting out the TTL.
+	trades.Post("/idempotency-keys/purge", PurgeIdempotencyKeysHandler(tr.idempotencyRC))
+
 	// Analytics routes
 	trades.Get("/analytics/summary", tr.GetTradingSummary)
 	trades.Get("/analytics/performance", tr.GetPerformanceMetrics)
+
+	// On-demand reconciliation, for a user who doesn't want to wait for
+	// the next cron pass after a broker sync.
+	trades.Post("/reconcile", middleware.RateLimitMiddleware(tr.limiter, createTradeRule), tr.reconciler.ReconcileHandler)
+
+	// Full history rebuild ("profit-fixer"): recomputes positions and P&L
+	// from the authoritative broker transaction history, independent of
+	// whatever the trades table currently says.
+	trades.Post("/reconcile/full", middleware.RateLimitMiddleware(tr.limiter, createTradeRule), tr.profitFixer.FullReconcileHandler)
+
+	// Backtesting: replay closed trades against a configurable strategy
+	// over historical klines and compare hypothetical vs. actual P&L.
+	trades.Post("/backtest", middleware.RateLimitMiddleware(tr.limiter, createTradeRule), tr.backtest.RunHandler)
+	trades.Get("/backtest", middleware.RateLimitMiddleware(tr.limiter, listTradesRule), tr.backtest.ListHandler)
+	trades.Get("/backtest/:id", middleware.RateLimitMiddleware(tr.limiter, listTradesRule), tr.backtest.GetHandler)
 }
 
 // CreateTrade handles trade creation
@@ -38,6 +85,11 @@ func (tr *TradeRouter) CreateTrade(c *fiber.Ctx) error {
 	return tr.tradeService.CreateTradeHandler(c)
 }
 
+// BulkImportTrades handles importing a batch of trades in one request
+func (tr *TradeRouter) BulkImportTrades(c *fiber.Ctx) error {
+	return tr.tradeService.BulkImportTradesHandler(c)
+}
+
 // GetTrades handles getting all trades for a user
 func (tr *TradeRouter) GetTrades(c *fiber.Ctx) error {
 	return tr.tradeService.GetTradesHandler(c)
@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"github.com/Johnson-f/tradistry_backend/middleware"
 	"github.com/Johnson-f/tradistry_backend/services"
 	"github.com/gofiber/fiber/v2"
 )
@@ -8,12 +9,14 @@ import (
 // UserRouter handles user-related routes
 type UserRouter struct {
 	userService services.UserServiceInterface
+	authService *services.AuthService
 }
 
 // NewUserRouter creates a new user router instance
-func NewUserRouter(userService services.UserServiceInterface) *UserRouter {
+func NewUserRouter(userService services.UserServiceInterface, authService *services.AuthService) *UserRouter {
 	return &UserRouter{
 		userService: userService,
+		authService: authService,
 	}
 }
 
@@ -26,6 +29,13 @@ func (ur *UserRouter) SetupUserRoutes(api fiber.Router) {
 	users.Get("/:id", ur.GetUser)
 	users.Put("/:id", ur.UpdateUser)
 	users.Delete("/:id", ur.DeleteUser)
+	users.Get("/:id/events", middleware.AuthMiddleware(ur.authService), ur.GetEvents)
+
+	keys := users.Group("/:id/keys", middleware.FlexibleAuthMiddleware(ur.authService))
+	keys.Get("/", ur.ListKeys)
+	keys.Post("/", ur.CreateKey)
+	keys.Put("/:keyId", ur.UpdateKey)
+	keys.Delete("/:keyId", ur.DeleteKey)
 }
 
 // CreateUser handles user creation
@@ -52,3 +62,28 @@ func (ur *UserRouter) UpdateUser(c *fiber.Ctx) error {
 func (ur *UserRouter) DeleteUser(c *fiber.Ctx) error {
 	return ur.userService.DeleteUserHandler(c)
 }
+
+// GetEvents handles the user's action_events audit timeline
+func (ur *UserRouter) GetEvents(c *fiber.Ctx) error {
+	return ur.userService.GetEventsHandler(c)
+}
+
+// ListKeys handles listing a user's API keys
+func (ur *UserRouter) ListKeys(c *fiber.Ctx) error {
+	return ur.userService.ListKeysHandler(c)
+}
+
+// CreateKey handles minting a new API key for a user
+func (ur *UserRouter) CreateKey(c *fiber.Ctx) error {
+	return ur.userService.CreateKeyHandler(c)
+}
+
+// UpdateKey handles updating an existing API key
+func (ur *UserRouter) UpdateKey(c *fiber.Ctx) error {
+	return ur.userService.UpdateKeyHandler(c)
+}
+
+// DeleteKey handles revoking an API key
+func (ur *UserRouter) DeleteKey(c *fiber.Ctx) error {
+	return ur.userService.DeleteKeyHandler(c)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+)
+
+const actionEventsTable = "action_events"
+
+// ActionEventRepository persists the per-account audit/activity log that
+// AuthService and the user/trade services instrument.
+type ActionEventRepository struct {
+	db *DatabaseService
+}
+
+// NewActionEventRepository creates a new ActionEventRepository
+func NewActionEventRepository(db *DatabaseService) *ActionEventRepository {
+	return &ActionEventRepository{db: db}
+}
+
+// Record inserts a single audit event. Callers treat a failure here as
+// non-fatal to the action being recorded.
+func (r *ActionEventRepository) Record(ctx context.Context, event models.ActionEvent) error {
+	if err := r.db.Insert(ctx, actionEventsTable, event); err != nil {
+		return fmt.Errorf("failed to record action event: %w", err)
+	}
+	return nil
+}
+
+// ListByAccount returns a page of events for accountID, most recent first.
+func (r *ActionEventRepository) ListByAccount(ctx context.Context, accountID int64, take, offset int) ([]models.ActionEvent, error) {
+	var events []models.ActionEvent
+	filters := map[string]interface{}{
+		"account_id": accountID,
+	}
+
+	err := r.db.SelectPage(ctx, actionEventsTable, "*", filters, "created_at", true, offset, take, &events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list action events: %w", err)
+	}
+	return events, nil
+}
+
+// CountByAccount returns the total number of events recorded for accountID.
+func (r *ActionEventRepository) CountByAccount(ctx context.Context, accountID int64) (int64, error) {
+	filters := map[string]interface{}{
+		"account_id": accountID,
+	}
+
+	count, err := r.db.Count(ctx, actionEventsTable, filters)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count action events: %w", err)
+	}
+	return count, nil
+}
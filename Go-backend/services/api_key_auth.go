@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+)
+
+const apiKeyTable = "api_keys"
+
+// ValidateAPIKey authenticates a raw "<keyid>.<secret>" API key, the
+// alternative to a Supabase JWT for user-owned automation. It looks the key
+// up by id, constant-time compares the SHA-256 of secret against the
+// persisted hash, and rejects expired keys. On success it returns an
+// AuthUser with role "api" carrying the key's granted scopes.
+func (a *AuthService) ValidateAPIKey(raw string) (*AuthUser, error) {
+	keyID, secret, ok := strings.Cut(raw, ".")
+	if !ok || keyID == "" || secret == "" {
+		return nil, fmt.Errorf("malformed api key")
+	}
+
+	ctx := context.Background()
+
+	var keys []models.ApiKey
+	_, err := a.supabase.From(apiKeyTable).Select("*", "", false).Eq("id", keyID).ExecuteTo(&keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up api key: %w", err)
+	}
+	if len(keys) == 0 {
+		a.recordActionEvent(ctx, 0, "api_key_validate_failed", keyID, "", "")
+		return nil, fmt.Errorf("api key not found")
+	}
+	key := keys[0]
+
+	sum := sha256.Sum256([]byte(secret))
+	hashed := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(hashed), []byte(key.HashedSecret)) != 1 {
+		a.recordActionEvent(ctx, key.AccountID, "api_key_validate_failed", keyID, "", "")
+		return nil, fmt.Errorf("invalid api key secret")
+	}
+
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		a.recordActionEvent(ctx, key.AccountID, "api_key_validate_failed", keyID, "", "")
+		return nil, fmt.Errorf("api key has expired")
+	}
+
+	a.touchAPIKeyLastUsed(ctx, keyID)
+	a.recordActionEvent(ctx, key.AccountID, "api_key_validate_succeeded", keyID, "", "")
+
+	return &AuthUser{
+		ID:     strconv.FormatInt(key.AccountID, 10),
+		Role:   "api",
+		Scopes: key.Scopes,
+	}, nil
+}
+
+func (a *AuthService) touchAPIKeyLastUsed(ctx context.Context, keyID string) {
+	a.supabase.From(apiKeyTable).
+		Update(map[string]interface{}{"last_used_at": time.Now()}, "", "").
+		Eq("id", keyID).
+		Execute()
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requireKeyOwner checks that idParam names the authenticated caller
+// (c.Locals("userID"), set by FlexibleAuthMiddleware) or that the caller
+// holds the service_role, the same ownership check GetEventsHandler
+// applies to a user's event timeline. It writes a 403 and returns false if
+// the caller isn't authorized to act on that account's api keys.
+func requireKeyOwner(c *fiber.Ctx, idParam string) bool {
+	callerRole, _ := c.Locals("userRole").(string)
+	callerID, _ := c.Locals("userID").(string)
+	if callerRole == "service_role" || callerID == idParam {
+		return true
+	}
+	c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "Not authorized to manage this user's api keys",
+	})
+	return false
+}
+
+// ListKeysHandler handles GET /users/:id/keys
+func (s *SupabaseUserService) ListKeysHandler(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	if !requireKeyOwner(c, idParam) {
+		return nil
+	}
+
+	userID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	keys, err := s.apiKeys.ListByAccount(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list api keys",
+		})
+	}
+
+	return c.JSON(keys)
+}
+
+type createKeyRequest struct {
+	Name        string     `json:"name" validate:"required"`
+	Description string     `json:"description"`
+	Scopes      []string   `json:"scopes" validate:"required"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateKeyHandler handles POST /users/:id/keys. The raw secret is returned
+// exactly once, in the response body - only its hash is persisted.
+func (s *SupabaseUserService) CreateKeyHandler(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	if !requireKeyOwner(c, idParam) {
+		return nil
+	}
+
+	userID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var req createKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	keyID, secret, hashedSecret, err := generateAPIKey()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate api key",
+		})
+	}
+
+	key := models.ApiKey{
+		ID:           keyID,
+		AccountID:    userID,
+		Name:         req.Name,
+		Description:  req.Description,
+		HashedSecret: hashedSecret,
+		Scopes:       req.Scopes,
+		ExpiresAt:    req.ExpiresAt,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.apiKeys.Create(c.Context(), key); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create api key",
+		})
+	}
+
+	s.recordEvent(c.Context(), userID, "api_key_created", keyID)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"id":     key.ID,
+		"name":   key.Name,
+		"scopes": key.Scopes,
+		"key":    keyID + "." + secret,
+	})
+}
+
+type updateKeyRequest struct {
+	Name        *string    `json:"name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// UpdateKeyHandler handles PUT /users/:id/keys/:keyId
+func (s *SupabaseUserService) UpdateKeyHandler(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	if !requireKeyOwner(c, idParam) {
+		return nil
+	}
+
+	userID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	keyID := c.Params("keyId")
+
+	var req updateKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Scopes != nil {
+		updates["scopes"] = req.Scopes
+	}
+	if req.ExpiresAt != nil {
+		updates["expires_at"] = *req.ExpiresAt
+	}
+
+	if err := s.apiKeys.Update(c.Context(), userID, keyID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update api key",
+		})
+	}
+
+	s.recordEvent(c.Context(), userID, "api_key_updated", keyID)
+
+	return c.JSON(fiber.Map{
+		"id": keyID,
+	})
+}
+
+// DeleteKeyHandler handles DELETE /users/:id/keys/:keyId
+func (s *SupabaseUserService) DeleteKeyHandler(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	if !requireKeyOwner(c, idParam) {
+		return nil
+	}
+
+	userID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	keyID := c.Params("keyId")
+
+	if err := s.apiKeys.Delete(c.Context(), userID, keyID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete api key",
+		})
+	}
+
+	s.recordEvent(c.Context(), userID, "api_key_deleted", keyID)
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// generateAPIKey produces a new "<keyid>.<secret>" pair and the SHA-256 hash
+// of secret to persist as ApiKey.HashedSecret.
+func generateAPIKey() (keyID, secret, hashedSecret string, err error) {
+	idBuf := make([]byte, 8)
+	if _, err = rand.Read(idBuf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key id: %w", err)
+	}
+
+	secretBuf := make([]byte, 24)
+	if _, err = rand.Read(secretBuf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	keyID = hex.EncodeToString(idBuf)
+	secret = hex.EncodeToString(secretBuf)
+
+	sum := sha256.Sum256([]byte(secret))
+	hashedSecret = hex.EncodeToString(sum[:])
+
+	return keyID, secret, hashedSecret, nil
+}
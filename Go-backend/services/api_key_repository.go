@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+)
+
+const apiKeysTable = "api_keys"
+
+// ApiKeyRepository persists the API keys an account mints for programmatic
+// access.
+type ApiKeyRepository struct {
+	db *DatabaseService
+}
+
+// NewApiKeyRepository creates a new ApiKeyRepository
+func NewApiKeyRepository(db *DatabaseService) *ApiKeyRepository {
+	return &ApiKeyRepository{db: db}
+}
+
+// Create persists a new API key row.
+func (r *ApiKeyRepository) Create(ctx context.Context, key models.ApiKey) error {
+	if err := r.db.Insert(ctx, apiKeysTable, key); err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// ListByAccount returns every API key belonging to accountID.
+func (r *ApiKeyRepository) ListByAccount(ctx context.Context, accountID int64) ([]models.ApiKey, error) {
+	var keys []models.ApiKey
+	filters := map[string]interface{}{
+		"account_id": accountID,
+	}
+
+	if err := r.db.Select(ctx, apiKeysTable, "*", filters, &keys); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// GetByID looks up a single API key by its id, regardless of owner - used
+// during ValidateAPIKey before the owning account is known.
+func (r *ApiKeyRepository) GetByID(ctx context.Context, id string) (*models.ApiKey, error) {
+	var keys []models.ApiKey
+	filters := map[string]interface{}{
+		"id": id,
+	}
+
+	if err := r.db.Select(ctx, apiKeysTable, "*", filters, &keys); err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return &keys[0], nil
+}
+
+// Update applies a partial update (e.g. name, description, scopes,
+// expires_at) to an API key owned by accountID.
+func (r *ApiKeyRepository) Update(ctx context.Context, accountID int64, id string, updates map[string]interface{}) error {
+	filters := map[string]interface{}{
+		"id":         id,
+		"account_id": accountID,
+	}
+
+	if err := r.db.Update(ctx, apiKeysTable, updates, filters); err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an API key owned by accountID.
+func (r *ApiKeyRepository) Delete(ctx context.Context, accountID int64, id string) error {
+	filters := map[string]interface{}{
+		"id":         id,
+		"account_id": accountID,
+	}
+
+	if err := r.db.Delete(ctx, apiKeysTable, filters); err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	return nil
+}
+
+// TouchLastUsed updates an API key's last_used_at timestamp.
+func (r *ApiKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	updates := map[string]interface{}{
+		"last_used_at": at,
+	}
+	filters := map[string]interface{}{
+		"id": id,
+	}
+
+	if err := r.db.Update(ctx, apiKeysTable, updates, filters); err != nil {
+		return fmt.Errorf("failed to touch api key: %w", err)
+	}
+	return nil
+}
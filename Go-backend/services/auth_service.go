@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supabase-community/supabase-go"
+)
+
+// supabaseJWKSPath is where a Supabase project publishes its signing keys.
+const supabaseJWKSPath = "/auth/v1/.well-known/jwks.json"
+
+// AuthService handles authentication operations
+type AuthService struct {
+	supabase *supabase.Client
+	config   *config.Config
+	jwks     *JWKSCache
+}
+
+// AuthUser represents the authenticated user
+type AuthUser struct {
+	ID          string                 `json:"id"`
+	Email       string                 `json:"email"`
+	Role        string                 `json:"role"`
+	Scopes      []string               `json:"scopes,omitempty"`
+	AppMetadata map[string]interface{} `json:"app_metadata,omitempty"`
+}
+
+// SupabaseClaims represents the JWT claims structure from Supabase
+type SupabaseClaims struct {
+	Sub         string                 `json:"sub"` // User ID
+	Email       string                 `json:"email"`
+	Role        string                 `json:"role"`
+	Aud         string                 `json:"aud"` // Audience
+	Exp         int64                  `json:"exp"` // Expiration time
+	Iat         int64                  `json:"iat"` // Issued at
+	Nbf         int64                  `json:"nbf"` // Not valid before
+	Iss         string                 `json:"iss"` // Issuer
+	AppMetadata map[string]interface{} `json:"app_metadata,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// NewAuthService creates a new authentication service. When JWT.JWKSURL
+// isn't explicitly configured but a Supabase project URL is, it defaults to
+// that project's published JWKS endpoint and warms the cache with a
+// best-effort fetch so the first real request isn't the one paying for it.
+// A cold/unreachable JWKS endpoint doesn't fail service creation - token
+// verification still has the legacy HMAC path to fall back to.
+func NewAuthService(cfg *config.Config) (*AuthService, error) {
+	client, err := supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.AnonKey, &supabase.ClientOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create supabase client: %w", err)
+	}
+
+	if cfg.JWT.JWKSURL == "" && cfg.Supabase.URL != "" {
+		cfg.JWT.JWKSURL = strings.TrimSuffix(cfg.Supabase.URL, "/") + supabaseJWKSPath
+	}
+
+	a := &AuthService{
+		supabase: client,
+		config:   cfg,
+		jwks:     NewJWKSCache(cfg.JWT.CacheTTL),
+	}
+
+	if cfg.JWT.JWKSURL != "" {
+		if _, err := a.jwks.Get(cfg.JWT.JWKSURL); err != nil {
+			log.Printf("Warning: failed to warm jwks cache from %s: %v", cfg.JWT.JWKSURL, err)
+		}
+	}
+
+	return a, nil
+}
+
+// ValidateToken validates a Supabase JWT token and extracts user claims. It
+// records a best-effort action_events row for both outcomes so operators
+// have a security timeline of token validation attempts. When
+// JWT.JWKSURL is configured, tokens are verified against the asymmetric
+// keys published there; otherwise it falls back to the legacy HMAC secret
+// so deployments that haven't set JWKSURL keep working unchanged.
+func (a *AuthService) ValidateToken(tokenString string) (*AuthUser, error) {
+	// Clean the token (remove "Bearer " prefix if present)
+	cleanToken := strings.TrimPrefix(tokenString, "Bearer ")
+
+	if cleanToken == "" {
+		return nil, fmt.Errorf("token is required")
+	}
+
+	var claims *SupabaseClaims
+	var err error
+	if a.config.JWT.JWKSURL != "" {
+		claims, err = a.parseWithJWKS(cleanToken)
+	} else {
+		claims, err = a.parseWithHMAC(cleanToken)
+	}
+	if err != nil {
+		a.recordActionEvent(context.Background(), 0, "token_validate_failed", err.Error(), "", "")
+		return nil, err
+	}
+
+	if verifyErr := verifyRegisteredClaims(claims, a.config.JWT.Issuer, a.config.JWT.Audience); verifyErr != nil {
+		a.recordActionEvent(context.Background(), accountIDFromSub(claims.Sub), "token_validate_failed", verifyErr.Error(), "", "")
+		return nil, verifyErr
+	}
+
+	a.recordActionEvent(context.Background(), accountIDFromSub(claims.Sub), "token_validate_succeeded", "", "", "")
+
+	// Extract user information from claims
+	return &AuthUser{
+		ID:          claims.Sub,
+		Email:       claims.Email,
+		Role:        claims.Role,
+		AppMetadata: claims.AppMetadata,
+	}, nil
+}
+
+// parseWithHMAC is the legacy verification path for tokens signed with the
+// shared JWT.Secret.
+func (a *AuthService) parseWithHMAC(cleanToken string) (*SupabaseClaims, error) {
+	token, err := jwt.ParseWithClaims(cleanToken, &SupabaseClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(a.config.JWT.Secret), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*SupabaseClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
+}
+
+// parseWithJWKS verifies an asymmetrically-signed token against the keys
+// published at JWT.JWKSURL, matching the token's "kid" header to a JWKS
+// entry and rejecting any algorithm the deployment hasn't opted into via
+// JWT.Algorithms (including "none").
+func (a *AuthService) parseWithJWKS(cleanToken string) (*SupabaseClaims, error) {
+	token, err := jwt.ParseWithClaims(cleanToken, &SupabaseClaims{}, func(token *jwt.Token) (interface{}, error) {
+		alg, _ := token.Header["alg"].(string)
+		if alg == "" || alg == "none" {
+			return nil, fmt.Errorf("unsupported signing algorithm: %q", alg)
+		}
+		if !algAllowed(alg, a.config.JWT.Algorithms) {
+			return nil, fmt.Errorf("signing algorithm %q is not permitted", alg)
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		keys, err := a.jwks.Get(a.config.JWT.JWKSURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwks: %w", err)
+		}
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("no jwks entry for kid %q", kid)
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			if _, ok := key.Pub.(*rsa.PublicKey); !ok {
+				return nil, fmt.Errorf("jwks key for kid %q is not an rsa key", kid)
+			}
+		case *jwt.SigningMethodECDSA:
+			if _, ok := key.Pub.(*ecdsa.PublicKey); !ok {
+				return nil, fmt.Errorf("jwks key for kid %q is not an ec key", kid)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported signing method: %v", token.Header["alg"])
+		}
+
+		return key.Pub, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*SupabaseClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	return claims, nil
+}
+
+// algAllowed reports whether alg is in the deployment's configured
+// allow-list. An empty allow-list denies everything - a misconfigured
+// JWKSURL without Algorithms shouldn't silently accept any algorithm.
+func algAllowed(alg string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyRegisteredClaims explicitly checks exp/nbf/iat, plus iss/aud when
+// the deployment has configured expected values, rather than relying
+// solely on whatever jwt-go's default validator enforces.
+func verifyRegisteredClaims(claims *SupabaseClaims, wantIssuer, wantAudience string) error {
+	now := time.Now().Unix()
+
+	if claims.Exp > 0 && now > claims.Exp {
+		return fmt.Errorf("token has expired")
+	}
+	if claims.Nbf > 0 && now < claims.Nbf {
+		return fmt.Errorf("token is not yet valid")
+	}
+	if claims.Iat > 0 && claims.Iat > now {
+		return fmt.Errorf("token issued in the future")
+	}
+	if wantIssuer != "" && claims.Iss != wantIssuer {
+		return fmt.Errorf("unexpected token issuer")
+	}
+	if wantAudience != "" && claims.Aud != wantAudience {
+		return fmt.Errorf("unexpected token audience")
+	}
+	return nil
+}
+
+// accountIDFromSub best-effort parses a numeric account id out of the JWT
+// subject, returning 0 (unattributed) when the subject isn't numeric - e.g.
+// a Supabase auth UUID rather than an internal account id.
+func accountIDFromSub(sub string) int64 {
+	id, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// StartJWKSRefresh runs the JWKS background refresher until ctx is
+// cancelled. It is a no-op when JWKSURL isn't configured. Callers should
+// invoke it in its own goroutine, e.g. `go authService.StartJWKSRefresh(ctx)`.
+func (a *AuthService) StartJWKSRefresh(ctx context.Context) {
+	if a.config.JWT.JWKSURL == "" {
+		return
+	}
+	a.jwks.Start(ctx)
+}
+
+// GetAuthenticatedClient returns a Supabase client with authentication
+func (a *AuthService) GetAuthenticatedClient(accessToken string) (*supabase.Client, error) {
+	// Create authenticated client using service key for backend operations
+	client, err := supabase.NewClient(a.config.Supabase.URL, a.config.Supabase.ServiceKey, &supabase.ClientOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authenticated client: %w", err)
+	}
+
+	return client, nil
+}
+
+// GetUserFromToken extracts user information from a token
+func (a *AuthService) GetUserFromToken(tokenString string) (*AuthUser, error) {
+	return a.ValidateToken(tokenString)
+}
+
+// GetUserIDFromToken extracts just the user ID from a JWT token
+func (a *AuthService) GetUserIDFromToken(tokenString string) (string, error) {
+	user, err := a.ValidateToken(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
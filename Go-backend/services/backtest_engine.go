@@ -0,0 +1,489 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// backtestRunsTable is the table BacktestEngine persists runs to.
+const backtestRunsTable = "backtest_runs"
+
+// BacktestEngine replays a user's closed trades against a configurable
+// strategy over historical klines, comparing the strategy's hypothetical
+// exit to what the trade actually did. It never touches the trades table -
+// Rebuild reads closed trades, simulates against bars from klines, and
+// writes the resulting report to backtest_runs.
+type BacktestEngine struct {
+	db     *DatabaseService
+	klines KlineProvider
+}
+
+// NewBacktestEngine builds a BacktestEngine.
+func NewBacktestEngine(db *DatabaseService, klines KlineProvider) *BacktestEngine {
+	return &BacktestEngine{db: db, klines: klines}
+}
+
+// Run replays cfg against userID's closed trades in [cfg.StartTime,
+// cfg.EndTime] and persists the resulting BacktestRun.
+func (e *BacktestEngine) Run(ctx context.Context, userID int64, cfg models.BacktestConfig) (*models.BacktestRun, error) {
+	if err := validateBacktestConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	trades, err := e.closedTrades(ctx, userID, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch closed trades: %w", err)
+	}
+
+	attributions := make([]models.TradeAttribution, 0, len(trades))
+	for _, trade := range trades {
+		attributions = append(attributions, e.replayTrade(ctx, trade, cfg))
+	}
+
+	report := buildReport(attributions)
+
+	run := &models.BacktestRun{
+		UserID:    userID,
+		Config:    cfg,
+		Report:    report,
+		CreatedAt: time.Now(),
+	}
+
+	if err := e.store(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to store backtest run: %w", err)
+	}
+
+	return run, nil
+}
+
+// closedTrades loads the user's closed trades whose entry date falls
+// inside the config's window, optionally restricted to cfg.Symbols.
+func (e *BacktestEngine) closedTrades(ctx context.Context, userID int64, cfg models.BacktestConfig) ([]models.Trade, error) {
+	opts := SelectOptions{
+		Eq: map[string]interface{}{
+			"user_id": userID,
+			"status":  models.TradeClosed,
+		},
+		Gte: map[string]interface{}{"entry_date": cfg.StartTime.Format(time.RFC3339)},
+		Lte: map[string]interface{}{"entry_date": cfg.EndTime.Format(time.RFC3339)},
+	}
+	if len(cfg.Symbols) > 0 {
+		opts.In = map[string][]string{"symbol": cfg.Symbols}
+	}
+
+	var trades []models.Trade
+	if err := e.db.SelectWithOptions(ctx, "trades", "*", opts, &trades); err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// replayTrade simulates cfg's strategy bar-by-bar from trade's entry
+// through cfg.EndTime, returning the comparison against the trade's
+// actual outcome. Fills are simulated at OHLC boundaries: a bar's
+// High/Low are checked against the strategy's current stop/target before
+// its Close is used to update trailing state, so a bar that touches the
+// exit level fills there rather than waiting for the close.
+func (e *BacktestEngine) replayTrade(ctx context.Context, trade models.Trade, cfg models.BacktestConfig) models.TradeAttribution {
+	attribution := models.TradeAttribution{
+		TradeID:   trade.ID,
+		Symbol:    trade.Symbol,
+		EntryDate: trade.EntryDate,
+	}
+	if trade.PnL != nil {
+		attribution.ActualPnL = *trade.PnL
+	}
+	if trade.ExitDate != nil {
+		attribution.ActualExitDate = *trade.ExitDate
+	}
+
+	bars, err := e.klines.FetchKlines(ctx, trade.Symbol, trade.EntryDate, cfg.EndTime)
+	if err != nil {
+		attribution.Skipped = true
+		attribution.SkippedReason = fmt.Sprintf("failed to fetch klines: %v", err)
+		return attribution
+	}
+	if len(bars) == 0 {
+		attribution.Skipped = true
+		attribution.SkippedReason = "no klines available for symbol/window"
+		return attribution
+	}
+
+	sim := newStrategySimulator(cfg, trade)
+	exitBar, exitPrice, found := sim.run(bars)
+	if !found {
+		attribution.Skipped = true
+		attribution.SkippedReason = "strategy never exited within the window"
+		return attribution
+	}
+
+	sign := 1.0
+	if trade.Type == models.TradeSell {
+		sign = -1.0
+	}
+	attribution.SimulatedExit = exitBar.Timestamp
+	attribution.SimulatedPrice = exitPrice
+	attribution.SimulatedPnL = (exitPrice - trade.EntryPrice) * trade.Quantity * sign
+	attribution.Delta = attribution.SimulatedPnL - attribution.ActualPnL
+
+	return attribution
+}
+
+// strategySimulator holds the running state a strategy needs across bars:
+// the trailing high-water mark for BacktestTrailingStop, and the ATR
+// series for BacktestATRPin.
+type strategySimulator struct {
+	cfg   models.BacktestConfig
+	trade models.Trade
+	sign  float64
+
+	bestClose float64
+}
+
+func newStrategySimulator(cfg models.BacktestConfig, trade models.Trade) *strategySimulator {
+	sign := 1.0
+	if trade.Type == models.TradeSell {
+		sign = -1.0
+	}
+	return &strategySimulator{cfg: cfg, trade: trade, sign: sign, bestClose: trade.EntryPrice}
+}
+
+// run walks bars in order and returns the bar and price the strategy
+// would have exited at, or found=false if it never triggered.
+func (s *strategySimulator) run(bars []models.Kline) (exitBar models.Kline, exitPrice float64, found bool) {
+	var atr float64
+	atrSeries := computeATR(bars, atrPeriod(s.cfg))
+
+	for i, bar := range bars {
+		switch s.cfg.Strategy {
+		case models.BacktestATRPin:
+			atr = atrSeries[i]
+			if atr <= 0 {
+				continue
+			}
+			multiple := s.cfg.ATRMultiple
+			if multiple <= 0 {
+				multiple = 1.0
+			}
+			stop := s.trade.EntryPrice - s.sign*atr*multiple
+			target := s.trade.EntryPrice + s.sign*atr*multiple
+			if price, ok := s.boundaryFill(bar, stop, target); ok {
+				return bar, price, true
+			}
+
+		case models.BacktestTrailingStop:
+			if s.sign > 0 && bar.Close > s.bestClose {
+				s.bestClose = bar.Close
+			}
+			if s.sign < 0 && bar.Close < s.bestClose {
+				s.bestClose = bar.Close
+			}
+			pct := s.cfg.TrailingStopPct
+			if pct <= 0 {
+				pct = 0.05
+			}
+			stop := s.bestClose - s.sign*s.bestClose*pct
+			if price, ok := s.boundaryFill(bar, stop, math.Inf(int(s.sign))); ok {
+				return bar, price, true
+			}
+
+		case models.BacktestFixedR:
+			if s.trade.StopLoss == nil {
+				continue
+			}
+			riskDistance := math.Abs(s.trade.EntryPrice - *s.trade.StopLoss)
+			multiple := s.cfg.FixedRMultiple
+			if multiple <= 0 {
+				multiple = 2.0
+			}
+			target := s.trade.EntryPrice + s.sign*riskDistance*multiple
+			if price, ok := s.boundaryFill(bar, *s.trade.StopLoss, target); ok {
+				return bar, price, true
+			}
+		}
+	}
+
+	return models.Kline{}, 0, false
+}
+
+// boundaryFill checks whether bar's High/Low range touched stop or target
+// first, simulating a fill at the level itself rather than the bar's
+// close. For a long (sign > 0), stop is below entry and target above; for
+// a short the roles invert. When both are touched in the same bar, the
+// stop is assumed to fill first (the conservative assumption).
+func (s *strategySimulator) boundaryFill(bar models.Kline, stop, target float64) (float64, bool) {
+	if s.sign > 0 {
+		if bar.Low <= stop {
+			return stop, true
+		}
+		if !math.IsInf(target, 0) && bar.High >= target {
+			return target, true
+		}
+		return 0, false
+	}
+
+	if bar.High >= stop {
+		return stop, true
+	}
+	if !math.IsInf(target, 0) && bar.Low <= target {
+		return target, true
+	}
+	return 0, false
+}
+
+// computeATR returns a same-length series of Wilder's average true range,
+// one value per bar (zero until enough bars have accumulated).
+func computeATR(bars []models.Kline, period int) []float64 {
+	atr := make([]float64, len(bars))
+	if period <= 0 {
+		period = 14
+	}
+
+	var trSum float64
+	prevClose := 0.0
+	for i, bar := range bars {
+		tr := bar.High - bar.Low
+		if i > 0 {
+			tr = math.Max(tr, math.Max(math.Abs(bar.High-prevClose), math.Abs(bar.Low-prevClose)))
+		}
+		prevClose = bar.Close
+
+		if i < period {
+			trSum += tr
+			if i == period-1 {
+				atr[i] = trSum / float64(period)
+			}
+			continue
+		}
+		atr[i] = (atr[i-1]*float64(period-1) + tr) / float64(period)
+	}
+	return atr
+}
+
+func atrPeriod(cfg models.BacktestConfig) int {
+	if cfg.ATRPeriod > 0 {
+		return cfg.ATRPeriod
+	}
+	return 14
+}
+
+// buildReport rolls per-trade attributions up into the equity curve and
+// summary statistics of a BacktestReport. The equity curve and Sharpe/
+// Sortino ratios are built from simulated per-trade P&L, ordered by
+// simulated exit time - skipped trades contribute to counts but not to
+// the curve.
+func buildReport(attributions []models.TradeAttribution) models.BacktestReport {
+	report := models.BacktestReport{
+		TradeCount:   len(attributions),
+		Attributions: attributions,
+	}
+
+	replayed := make([]models.TradeAttribution, 0, len(attributions))
+	for _, a := range attributions {
+		report.ActualPnL += a.ActualPnL
+		if a.Skipped {
+			continue
+		}
+		report.SimulatedPnL += a.SimulatedPnL
+		replayed = append(replayed, a)
+	}
+
+	sort.Slice(replayed, func(i, j int) bool { return replayed[i].SimulatedExit.Before(replayed[j].SimulatedExit) })
+
+	var equity float64
+	var peak float64
+	var returns []float64
+	for _, a := range replayed {
+		equity += a.SimulatedPnL
+		report.EquityCurve = append(report.EquityCurve, models.EquityPoint{Timestamp: a.SimulatedExit, Equity: equity})
+		if equity > peak {
+			peak = equity
+		}
+		if drawdown := peak - equity; drawdown > report.MaxDrawdown {
+			report.MaxDrawdown = drawdown
+		}
+		returns = append(returns, a.SimulatedPnL)
+	}
+
+	report.SharpeRatio = backtestSharpeRatio(returns)
+	report.SortinoRatio = backtestSortinoRatio(returns)
+
+	return report
+}
+
+// backtestSharpeRatio is the mean per-trade return over its standard
+// deviation, unannualized (the unit here is "per trade", not "per year" -
+// there's no fixed bar cadence across symbols to annualize against). It's
+// distinct from trade_analytics.go's sharpeRatio, which annualizes against
+// a calendar window instead of a per-trade one.
+func backtestSharpeRatio(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := meanOf(returns)
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	stddev := math.Sqrt(variance)
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev
+}
+
+// backtestSortinoRatio is the same as backtestSharpeRatio but only
+// penalizes downside deviation (negative returns), rewarding strategies
+// whose volatility is mostly to the upside.
+func backtestSortinoRatio(returns []float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := meanOf(returns)
+	var downside float64
+	var count int
+	for _, r := range returns {
+		if r < 0 {
+			downside += r * r
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	stddev := math.Sqrt(downside / float64(count))
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev
+}
+
+func meanOf(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// store persists run, letting Supabase assign the id, then reads it back
+// so the caller (and ListHandler/GetHandler afterwards) sees the assigned
+// id and created_at.
+func (e *BacktestEngine) store(ctx context.Context, run *models.BacktestRun) error {
+	var inserted []models.BacktestRun
+	if err := e.db.InsertMany(ctx, backtestRunsTable, []models.BacktestRun{*run}, &inserted); err != nil {
+		return err
+	}
+	if len(inserted) == 0 {
+		return fmt.Errorf("backtest run was inserted but not returned")
+	}
+	*run = inserted[0]
+	return nil
+}
+
+// validateBacktestConfig applies the same checks BacktestConfig's tags
+// describe, by hand - this codebase doesn't wire a struct-tag validator
+// into the request path (see validateTradeCreateRequest).
+func validateBacktestConfig(cfg models.BacktestConfig) error {
+	switch cfg.Strategy {
+	case models.BacktestATRPin, models.BacktestTrailingStop, models.BacktestFixedR:
+	default:
+		return fmt.Errorf("strategy must be one of atr_pin, trailing_stop, fixed_r")
+	}
+	if cfg.StartTime.IsZero() || cfg.EndTime.IsZero() {
+		return fmt.Errorf("start_time and end_time are required")
+	}
+	if !cfg.EndTime.After(cfg.StartTime) {
+		return fmt.Errorf("end_time must be after start_time")
+	}
+	return nil
+}
+
+// RunHandler handles POST /trades/backtest: replays the request's strategy
+// config against the caller's closed trades. The body is parsed as YAML
+// when Content-Type is application/x-yaml or text/yaml (for uploaded
+// strategy config files), and as JSON otherwise.
+func (e *BacktestEngine) RunHandler(c *fiber.Ctx) error {
+	userID, err := userIDFromLocals(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var cfg models.BacktestConfig
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	if strings.Contains(contentType, "yaml") {
+		if err := yaml.Unmarshal(c.Body(), &cfg); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid yaml config: " + err.Error()})
+		}
+	} else {
+		var req models.BacktestRunRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+		cfg = req.Config
+	}
+
+	run, err := e.Run(c.Context(), userID, cfg)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(run)
+}
+
+// ListHandler handles GET /trades/backtest: the caller's past runs, most
+// recent first, so two runs can be fetched by id afterwards and diffed
+// client-side.
+func (e *BacktestEngine) ListHandler(c *fiber.Ctx) error {
+	userID, err := userIDFromLocals(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	var runs []models.BacktestRun
+	if err := e.db.SelectPage(c.Context(), backtestRunsTable, "*", map[string]interface{}{
+		"user_id": userID,
+	}, "created_at", true, 0, 50, &runs); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": runs})
+}
+
+// GetHandler handles GET /trades/backtest/:id: returns one persisted run,
+// scoped to the caller so one user can't read another's backtest history.
+func (e *BacktestEngine) GetHandler(c *fiber.Ctx) error {
+	userID, err := userIDFromLocals(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	id := c.Params("id")
+	var runs []models.BacktestRun
+	if err := e.db.Select(c.Context(), backtestRunsTable, "*", map[string]interface{}{
+		"id":      id,
+		"user_id": userID,
+	}, &runs); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(runs) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "backtest run not found"})
+	}
+
+	return c.JSON(runs[0])
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/Johnson-f/tradistry_backend/config"
+	"github.com/supabase-community/postgrest-go"
 	"github.com/supabase-community/supabase-go"
 )
 
@@ -46,15 +47,27 @@ func (db *DatabaseService) Insert(ctx context.Context, table string, data interf
 	return nil
 }
 
+// InsertMany inserts every row in rows into table as a single request,
+// decoding the inserted rows Supabase hands back into result. This is the
+// bulk counterpart to Insert: a caller importing thousands of rows gets one
+// round trip instead of an Insert-then-Select per row.
+func (db *DatabaseService) InsertMany(ctx context.Context, table string, rows interface{}, result interface{}) error {
+	_, err := db.client.From(table).Insert(rows, false, "", "representation", "").ExecuteTo(result)
+	if err != nil {
+		return fmt.Errorf("failed to bulk insert into %s: %w", table, err)
+	}
+	return nil
+}
+
 // Select performs a select query on the specified table
 func (db *DatabaseService) Select(ctx context.Context, table string, columns string, filters map[string]interface{}, result interface{}) error {
 	query := db.client.From(table).Select(columns, "", false)
-	
+
 	// Apply filters
 	for key, value := range filters {
 		query = query.Eq(key, fmt.Sprintf("%v", value))
 	}
-	
+
 	_, err := query.ExecuteTo(result)
 	if err != nil {
 		return fmt.Errorf("failed to select from %s: %w", table, err)
@@ -62,15 +75,25 @@ func (db *DatabaseService) Select(ctx context.Context, table string, columns str
 	return nil
 }
 
+// Upsert inserts or updates a record in the specified table, resolving
+// conflicts on onConflict (a comma-separated list of column names).
+func (db *DatabaseService) Upsert(ctx context.Context, table string, data interface{}, onConflict string) error {
+	_, _, err := db.client.From(table).Upsert(data, onConflict, "", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to upsert into %s: %w", table, err)
+	}
+	return nil
+}
+
 // Update updates records in the specified table
 func (db *DatabaseService) Update(ctx context.Context, table string, data interface{}, filters map[string]interface{}) error {
 	query := db.client.From(table).Update(data, "", "")
-	
+
 	// Apply filters
 	for key, value := range filters {
 		query = query.Eq(key, fmt.Sprintf("%v", value))
 	}
-	
+
 	_, _, err := query.Execute()
 	if err != nil {
 		return fmt.Errorf("failed to update %s: %w", table, err)
@@ -81,12 +104,12 @@ func (db *DatabaseService) Update(ctx context.Context, table string, data interf
 // Delete deletes records from the specified table
 func (db *DatabaseService) Delete(ctx context.Context, table string, filters map[string]interface{}) error {
 	query := db.client.From(table).Delete("", "")
-	
+
 	// Apply filters
 	for key, value := range filters {
 		query = query.Eq(key, fmt.Sprintf("%v", value))
 	}
-	
+
 	_, _, err := query.Execute()
 	if err != nil {
 		return fmt.Errorf("failed to delete from %s: %w", table, err)
@@ -94,6 +117,113 @@ func (db *DatabaseService) Delete(ctx context.Context, table string, filters map
 	return nil
 }
 
+// SelectPage performs a select query on the specified table, ordered by
+// orderColumn (descending when desc is true) and limited to a page of rows
+// starting at offset.
+func (db *DatabaseService) SelectPage(ctx context.Context, table, columns string, filters map[string]interface{}, orderColumn string, desc bool, offset, limit int, result interface{}) error {
+	query := db.client.From(table).Select(columns, "", false)
+
+	for key, value := range filters {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+
+	query = query.Order(orderColumn, &postgrest.OrderOpts{Ascending: !desc})
+	query = query.Range(offset, offset+limit-1, "")
+
+	_, err := query.ExecuteTo(result)
+	if err != nil {
+		return fmt.Errorf("failed to select page from %s: %w", table, err)
+	}
+	return nil
+}
+
+// SelectOptions configures SelectWithOptions. Eq holds exact-match filters
+// (same as Select's filters map); Gte/Lte add column >=/<= value bounds;
+// In adds a column IN (values) filter. OrderColumn/OrderDesc set the sort,
+// and Limit bounds the page size - callers doing keyset pagination also set
+// AfterOrderValue/AfterID to continue from the last row of the previous
+// page instead of paging by offset.
+type SelectOptions struct {
+	Eq  map[string]interface{}
+	Gte map[string]interface{}
+	Lte map[string]interface{}
+	In  map[string][]string
+
+	OrderColumn string
+	OrderDesc   bool
+	Limit       int
+
+	// AfterOrderValue/AfterID implement keyset pagination on
+	// (OrderColumn, id): only rows strictly past this cursor are
+	// returned, in the same order as OrderColumn/OrderDesc.
+	AfterOrderValue string
+	AfterID         int64
+}
+
+// SelectWithOptions performs a select query against table with the
+// filters, ordering, and keyset pagination described by opts, writing the
+// decoded rows into result. Keyset pagination (via AfterOrderValue/AfterID)
+// is preferred over offset-based paging here since it stays stable as rows
+// are inserted or deleted ahead of the page being read.
+func (db *DatabaseService) SelectWithOptions(ctx context.Context, table, columns string, opts SelectOptions, result interface{}) error {
+	query := db.client.From(table).Select(columns, "", false)
+
+	for key, value := range opts.Eq {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+	for key, value := range opts.Gte {
+		query = query.Gte(key, fmt.Sprintf("%v", value))
+	}
+	for key, value := range opts.Lte {
+		query = query.Lte(key, fmt.Sprintf("%v", value))
+	}
+	for key, values := range opts.In {
+		query = query.In(key, values)
+	}
+
+	if opts.OrderColumn != "" {
+		query = query.Order(opts.OrderColumn, &postgrest.OrderOpts{Ascending: !opts.OrderDesc})
+		query = query.Order("id", &postgrest.OrderOpts{Ascending: !opts.OrderDesc})
+	}
+
+	if opts.AfterOrderValue != "" {
+		cmp := "gt"
+		if opts.OrderDesc {
+			cmp = "lt"
+		}
+		query = query.Or(fmt.Sprintf(
+			"%s.%s.%s,and(%s.eq.%s,id.%s.%d)",
+			opts.OrderColumn, cmp, opts.AfterOrderValue,
+			opts.OrderColumn, opts.AfterOrderValue, cmp, opts.AfterID,
+		), "")
+	}
+
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit, "")
+	}
+
+	_, err := query.ExecuteTo(result)
+	if err != nil {
+		return fmt.Errorf("failed to select from %s: %w", table, err)
+	}
+	return nil
+}
+
+// Count returns the number of rows in table matching filters.
+func (db *DatabaseService) Count(ctx context.Context, table string, filters map[string]interface{}) (int64, error) {
+	query := db.client.From(table).Select("*", "exact", true)
+
+	for key, value := range filters {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+
+	_, count, err := query.Execute()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return count, nil
+}
+
 // HealthCheck verifies the database connection
 func (db *DatabaseService) HealthCheck(ctx context.Context) error {
 	// Simple health check by trying to select from a system table
@@ -102,7 +232,7 @@ func (db *DatabaseService) HealthCheck(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
-	
+
 	log.Printf("Database health check passed. Found %d tables", len(result))
 	return nil
 }
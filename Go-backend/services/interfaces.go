@@ -14,11 +14,17 @@ type UserServiceInterface interface {
 	GetUserHandler(c *fiber.Ctx) error
 	UpdateUserHandler(c *fiber.Ctx) error
 	DeleteUserHandler(c *fiber.Ctx) error
+	GetEventsHandler(c *fiber.Ctx) error
+	ListKeysHandler(c *fiber.Ctx) error
+	CreateKeyHandler(c *fiber.Ctx) error
+	UpdateKeyHandler(c *fiber.Ctx) error
+	DeleteKeyHandler(c *fiber.Ctx) error
 }
 
 // TradeServiceInterface defines the contract for trade services
 type TradeServiceInterface interface {
 	CreateTradeHandler(c *fiber.Ctx) error
+	BulkImportTradesHandler(c *fiber.Ctx) error
 	GetTradesHandler(c *fiber.Ctx) error
 	GetTradeHandler(c *fiber.Ctx) error
 	UpdateTradeHandler(c *fiber.Ctx) error
@@ -40,6 +46,14 @@ type UserRepositoryInterface interface {
 	UserExists(ctx context.Context, email, username string) (bool, error)
 }
 
+// ActionEventRepositoryInterface defines the contract for the per-account
+// audit/activity log.
+type ActionEventRepositoryInterface interface {
+	Record(ctx context.Context, event models.ActionEvent) error
+	ListByAccount(ctx context.Context, accountID int64, take, offset int) ([]models.ActionEvent, error)
+	CountByAccount(ctx context.Context, accountID int64) (int64, error)
+}
+
 // TradeRepositoryInterface defines the contract for trade data operations
 type TradeRepositoryInterface interface {
 	CreateTrade(ctx context.Context, userID int64, req models.TradeCreateRequest) (*models.Trade, error)
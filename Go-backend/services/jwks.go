@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// negativeCacheTTL bounds how long a failed JWKS fetch is remembered before
+// the next call is allowed to hit the network again, so an outage doesn't
+// turn every request into a retry storm against the identity provider.
+const negativeCacheTTL = 30 * time.Second
+
+// jwksKey is a single, already-decoded key from a JWKS document, ready to be
+// handed to jwt.Parser as the verification key.
+type jwksKey struct {
+	Alg string
+	Pub interface{}
+}
+
+// jwksSet is a parsed "keys" document keyed by "kid".
+type jwksSet struct {
+	fetchedAt time.Time
+	keys      map[string]jwksKey
+
+	// err/errUntil implement the negative cache: a failed fetch is
+	// remembered for negativeCacheTTL before being retried.
+	err      error
+	errUntil time.Time
+}
+
+// rawJWK mirrors the subset of RFC 7517 fields this service understands:
+// RSA (kty=RSA, n/e) and EC (kty=EC, crv/x/y).
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type rawJWKS struct {
+	Keys []rawJWK `json:"keys"`
+}
+
+// JWKSCache fetches and caches JWKS documents by URL, refreshing them in the
+// background and coalescing concurrent fetches of the same URL so a cache
+// miss under load results in a single request to the identity provider
+// rather than one per waiting caller.
+type JWKSCache struct {
+	mu   sync.Mutex
+	sets map[string]*jwksSet
+	ttl  time.Duration
+
+	httpClient *http.Client
+}
+
+// NewJWKSCache creates a cache that treats fetched documents as fresh for
+// ttl before refreshing them.
+func NewJWKSCache(ttl time.Duration) *JWKSCache {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &JWKSCache{
+		sets:       make(map[string]*jwksSet),
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Get returns the keyed JWKS entries for url, fetching (or refreshing) them
+// if the cached copy is missing or stale. The mutex is held for the
+// duration of a refresh, so concurrent callers for the same (or a
+// different) URL queue behind one another rather than each dialing the
+// identity provider - a deliberately coarse single-flight.
+func (c *JWKSCache) Get(url string) (map[string]jwksKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	set, ok := c.sets[url]
+	if ok && now.Before(set.fetchedAt.Add(c.ttl)) {
+		return set.keys, nil
+	}
+	if ok && set.err != nil && now.Before(set.errUntil) {
+		// Fall back to the last good set, if any, rather than hard-failing
+		// every request for the duration of the negative cache window.
+		if len(set.keys) > 0 {
+			return set.keys, nil
+		}
+		return nil, set.err
+	}
+
+	keys, fetchErr := c.fetch(url)
+	if fetchErr != nil {
+		failed := &jwksSet{err: fetchErr, errUntil: now.Add(negativeCacheTTL)}
+		if ok {
+			failed.keys = set.keys
+			failed.fetchedAt = set.fetchedAt
+		}
+		c.sets[url] = failed
+		if len(failed.keys) > 0 {
+			return failed.keys, nil
+		}
+		return nil, fetchErr
+	}
+
+	c.sets[url] = &jwksSet{keys: keys, fetchedAt: now}
+	return keys, nil
+}
+
+// Refresh proactively re-fetches every URL this cache has previously served,
+// intended to be called from a background ticker so steady traffic never
+// has to pay for a synchronous refresh.
+func (c *JWKSCache) Refresh() {
+	c.mu.Lock()
+	urls := make([]string, 0, len(c.sets))
+	for url := range c.sets {
+		urls = append(urls, url)
+	}
+	c.mu.Unlock()
+
+	for _, url := range urls {
+		c.Get(url)
+	}
+}
+
+// Start runs a background refresh loop until ctx is cancelled, keeping
+// already-seen JWKS documents warm ahead of their TTL expiring.
+func (c *JWKSCache) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Refresh()
+		}
+	}
+}
+
+func (c *JWKSCache) fetch(url string) (map[string]jwksKey, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks response: %w", err)
+	}
+
+	var set rawJWKS
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks response: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		pub, err := raw.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[raw.Kid] = jwksKey{Alg: raw.Alg, Pub: pub}
+	}
+	return keys, nil
+}
+
+// publicKey builds the crypto.PublicKey described by this JWK, supporting
+// RSA (n/e) and EC (crv/x/y) key types - the two families Supabase issues.
+func (k rawJWK) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa modulus: %w", err)
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rsa exponent: %w", err)
+		}
+
+		eBuf := make([]byte, 8)
+		copy(eBuf[8-len(eBytes):], eBytes)
+		e := int(binary.BigEndian.Uint64(eBuf))
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: e,
+		}, nil
+	case "EC":
+		curve, err := curveFor(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		xBytes, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec x coordinate: %w", err)
+		}
+		yBytes, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ec y coordinate: %w", err)
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(xBytes),
+			Y:     new(big.Int).SetBytes(yBytes),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwk key type: %s", k.Kty)
+	}
+}
+
+func curveFor(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported ec curve: %s", crv)
+	}
+}
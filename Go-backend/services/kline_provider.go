@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+)
+
+// KlineProvider fetches historical OHLCV bars for a symbol, ascending by
+// timestamp, over [start, end]. Backtest is the only caller; a pluggable
+// interface keeps it independent of wherever bar data actually lives
+// (a market-data vendor, a cached table, ...).
+type KlineProvider interface {
+	FetchKlines(ctx context.Context, symbol string, start, end time.Time) ([]models.Kline, error)
+}
+
+// klineRow is the shape of the shared "klines" table, kept by an out of
+// band ingestion job this codebase doesn't own.
+type klineRow struct {
+	Symbol    string  `json:"symbol"`
+	Timestamp string  `json:"timestamp"`
+	Open      float64 `json:"open"`
+	High      float64 `json:"high"`
+	Low       float64 `json:"low"`
+	Close     float64 `json:"close"`
+	Volume    float64 `json:"volume"`
+}
+
+// SupabaseKlineProvider reads cached bars from the shared "klines" table.
+type SupabaseKlineProvider struct {
+	db *DatabaseService
+}
+
+// NewSupabaseKlineProvider builds a SupabaseKlineProvider.
+func NewSupabaseKlineProvider(db *DatabaseService) *SupabaseKlineProvider {
+	return &SupabaseKlineProvider{db: db}
+}
+
+// FetchKlines implements KlineProvider.
+func (p *SupabaseKlineProvider) FetchKlines(ctx context.Context, symbol string, start, end time.Time) ([]models.Kline, error) {
+	var rows []klineRow
+	opts := SelectOptions{
+		Eq:          map[string]interface{}{"symbol": symbol},
+		Gte:         map[string]interface{}{"timestamp": start.Format(time.RFC3339)},
+		Lte:         map[string]interface{}{"timestamp": end.Format(time.RFC3339)},
+		OrderColumn: "timestamp",
+	}
+	if err := p.db.SelectWithOptions(ctx, "klines", "*", opts, &rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch klines for %s: %w", symbol, err)
+	}
+
+	klines := make([]models.Kline, 0, len(rows))
+	for _, row := range rows {
+		ts, err := time.Parse(time.RFC3339, row.Timestamp)
+		if err != nil {
+			continue
+		}
+		klines = append(klines, models.Kline{
+			Timestamp: ts,
+			Open:      row.Open,
+			High:      row.High,
+			Low:       row.Low,
+			Close:     row.Close,
+			Volume:    row.Volume,
+		})
+	}
+	return klines, nil
+}
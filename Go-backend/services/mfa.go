@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	challengeTable   = "mfa_challenges"
+	actionEventTable = "action_events"
+	authFactorTable  = "auth_factors"
+
+	// challengeTTL bounds how long a caller has to complete a challenge
+	// once AuthService.StartChallenge issues it.
+	challengeTTL = 5 * time.Minute
+
+	// maxChallengeAttempts is the number of verify attempts allowed against
+	// a single challenge before it's rejected outright.
+	maxChallengeAttempts = 5
+
+	// totpStep and totpDriftSteps implement RFC 6238 with a +/-1 step
+	// tolerance so a slightly out-of-sync authenticator clock still works.
+	totpStep       = 30 * time.Second
+	totpDriftSteps = 1
+)
+
+// ChallengeFactor is the caller-facing summary of an enrolled factor
+// returned from StartChallenge - it omits the factor's secret material.
+type ChallengeFactor struct {
+	ID   string            `json:"id"`
+	Type models.FactorType `json:"type"`
+}
+
+// storedChallenge mirrors the mfa_challenges table row.
+type storedChallenge struct {
+	ID                string    `json:"id"`
+	UserID            int64     `json:"user_id"`
+	Fingerprint       string    `json:"fingerprint"`
+	RemainingAttempts int       `json:"remaining_attempts"`
+	Fulfilled         bool      `json:"fulfilled"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// StartChallenge persists a new MFA challenge for userID, keyed to the
+// caller's IP+User-Agent fingerprint, and returns the challenge plus the
+// factor list the caller should be prompted with. factors is the user's
+// enrolled AuthFactor rows, fetched by the caller via
+// SupabaseUserService.ListFactors.
+func (a *AuthService) StartChallenge(ctx context.Context, userID int64, ip, userAgent string, factors []models.AuthFactor) (*models.Challenge, []ChallengeFactor, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate challenge id: %w", err)
+	}
+
+	now := time.Now()
+	challenge := storedChallenge{
+		ID:                id,
+		UserID:            userID,
+		Fingerprint:       fingerprintOf(ip, userAgent),
+		RemainingAttempts: maxChallengeAttempts,
+		Fulfilled:         false,
+		ExpiresAt:         now.Add(challengeTTL),
+		CreatedAt:         now,
+	}
+
+	if _, _, err := a.supabase.From(challengeTable).Insert(challenge, false, "", "", "").Execute(); err != nil {
+		return nil, nil, fmt.Errorf("failed to persist challenge: %w", err)
+	}
+
+	a.recordActionEvent(ctx, userID, "mfa_challenge_started", challenge.ID, ip, userAgent)
+
+	available := make([]ChallengeFactor, 0, len(factors))
+	for _, f := range factors {
+		available = append(available, ChallengeFactor{ID: f.ID, Type: f.Type})
+	}
+
+	return &models.Challenge{
+		ID:                challenge.ID,
+		UserID:            challenge.UserID,
+		Fingerprint:       challenge.Fingerprint,
+		RemainingAttempts: challenge.RemainingAttempts,
+		Fulfilled:         challenge.Fulfilled,
+		ExpiresAt:         challenge.ExpiresAt,
+		CreatedAt:         challenge.CreatedAt,
+	}, available, nil
+}
+
+// VerifyChallenge validates a submitted factor response against the
+// challenge identified by challengeID+fingerprint. expectedUserID is the
+// user id the caller claims to be verifying and must match the user the
+// challenge was actually started for - otherwise a caller could pass
+// someone else's challenge id alongside their own factor/secret and still
+// have it validate. Factors are fetched internally against
+// challenge.UserID (never a caller-supplied id), so there's no way for the
+// caller to substitute their own enrolled factors for the challenged
+// user's. On success the challenge is marked fulfilled and a signed JWT is
+// returned via the existing JWTConfig path; on failure the challenge's
+// remaining-attempts counter is decremented.
+func (a *AuthService) VerifyChallenge(ctx context.Context, challengeID, ip, userAgent, factorID, secret string, expectedUserID int64) (string, error) {
+	challenge, err := a.loadChallenge(ctx, challengeID, fingerprintOf(ip, userAgent))
+	if err != nil {
+		return "", err
+	}
+
+	if challenge.UserID != expectedUserID {
+		return "", fmt.Errorf("user id does not match challenge")
+	}
+	if challenge.Fulfilled {
+		return "", fmt.Errorf("challenge already fulfilled")
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("challenge has expired")
+	}
+	if challenge.RemainingAttempts <= 0 {
+		return "", fmt.Errorf("no verification attempts remaining")
+	}
+
+	factors, err := a.loadFactors(ctx, challenge.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	var factor *models.AuthFactor
+	for i := range factors {
+		if factors[i].ID == factorID {
+			factor = &factors[i]
+			break
+		}
+	}
+	if factor == nil {
+		return "", fmt.Errorf("unknown factor id")
+	}
+
+	if !verifyFactor(*factor, secret) {
+		a.decrementChallengeAttempts(ctx, challenge)
+		a.recordActionEvent(ctx, challenge.UserID, "mfa_challenge_failed", challenge.ID, ip, userAgent)
+		return "", fmt.Errorf("invalid verification code")
+	}
+
+	if err := a.markChallengeFulfilled(ctx, challenge.ID); err != nil {
+		return "", err
+	}
+	a.recordActionEvent(ctx, challenge.UserID, "mfa_challenge_verified", challenge.ID, ip, userAgent)
+
+	token, err := a.mintToken(AuthUser{ID: strconv.FormatInt(challenge.UserID, 10)})
+	if err != nil {
+		return "", fmt.Errorf("failed to mint token: %w", err)
+	}
+	return token, nil
+}
+
+// verifyFactor validates secret against factor according to its type.
+func verifyFactor(factor models.AuthFactor, secret string) bool {
+	switch factor.Type {
+	case models.FactorTOTP:
+		return verifyTOTP(factor.Secret, secret, time.Now())
+	case models.FactorEmailOTP, models.FactorBackupCode:
+		return subtle.ConstantTimeCompare([]byte(factor.Secret), []byte(secret)) == 1
+	default:
+		return false
+	}
+}
+
+// verifyTOTP checks code against an RFC 6238 TOTP generated from the
+// base32-encoded seed, allowing +/-1 step of clock drift.
+func verifyTOTP(base32Seed, code string, at time.Time) bool {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(base32Seed)
+	if err != nil {
+		return false
+	}
+
+	counter := at.Unix() / int64(totpStep.Seconds())
+	for drift := -totpDriftSteps; drift <= totpDriftSteps; drift++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, counter+int64(drift))), []byte(code)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func totpCode(key []byte, counter int64) string {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(buf)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", truncated%1_000_000)
+}
+
+func (a *AuthService) loadChallenge(ctx context.Context, challengeID, fingerprint string) (*storedChallenge, error) {
+	var rows []storedChallenge
+	_, err := a.supabase.From(challengeTable).
+		Select("*", "", false).
+		Eq("id", challengeID).
+		Eq("fingerprint", fingerprint).
+		ExecuteTo(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load challenge: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("challenge not found")
+	}
+	return &rows[0], nil
+}
+
+// loadFactors fetches userID's enrolled MFA factors directly, so
+// VerifyChallenge never has to trust a caller-supplied factor list for a
+// user it hasn't independently verified the caller is.
+func (a *AuthService) loadFactors(ctx context.Context, userID int64) ([]models.AuthFactor, error) {
+	var factors []models.AuthFactor
+	_, err := a.supabase.From(authFactorTable).
+		Select("*", "", false).
+		Eq("user_id", strconv.FormatInt(userID, 10)).
+		ExecuteTo(&factors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load auth factors: %w", err)
+	}
+	return factors, nil
+}
+
+func (a *AuthService) decrementChallengeAttempts(ctx context.Context, challenge *storedChallenge) {
+	a.supabase.From(challengeTable).
+		Update(map[string]interface{}{"remaining_attempts": challenge.RemainingAttempts - 1}, "", "").
+		Eq("id", challenge.ID).
+		Execute()
+}
+
+func (a *AuthService) markChallengeFulfilled(ctx context.Context, challengeID string) error {
+	_, _, err := a.supabase.From(challengeTable).
+		Update(map[string]interface{}{"fulfilled": true}, "", "").
+		Eq("id", challengeID).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to mark challenge fulfilled: %w", err)
+	}
+	return nil
+}
+
+// recordActionEvent writes a best-effort row to the account_events audit
+// log (models.ActionEvent). A failure here shouldn't fail the MFA/auth flow
+// itself, so the error is dropped.
+func (a *AuthService) recordActionEvent(ctx context.Context, accountID int64, action, target, ip, userAgent string) {
+	a.supabase.From(actionEventTable).Insert(models.ActionEvent{
+		AccountID: accountID,
+		Action:    action,
+		Target:    target,
+		IP:        ip,
+		UserAgent: userAgent,
+		CreatedAt: time.Now(),
+	}, false, "", "", "").Execute()
+}
+
+// fingerprintOf derives the stable IP+User-Agent key a Challenge is scoped
+// to, so a leaked challenge id alone can't be used to attempt verification.
+func fingerprintOf(ip, userAgent string) string {
+	return ip + "|" + userAgent
+}
+
+// mintToken signs a fresh Supabase-shaped JWT for user using the existing
+// JWTConfig secret and expiry.
+func (a *AuthService) mintToken(user AuthUser) (string, error) {
+	now := time.Now()
+	claims := SupabaseClaims{
+		Sub:   user.ID,
+		Email: user.Email,
+		Role:  user.Role,
+		Iat:   now.Unix(),
+		Exp:   now.Add(time.Duration(a.config.JWT.ExpiryHours) * time.Hour).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(a.config.JWT.Secret))
+}
+
+func randomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
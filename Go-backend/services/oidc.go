@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDTokenClaims is the OIDC-standard claim set BuildIDToken signs and
+// /auth/userinfo returns, so the backend can act as a lightweight OIDC
+// provider for any client that already speaks the standard.
+type IDTokenClaims struct {
+	Sub               string `json:"sub"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	Name              string `json:"name"`
+	Picture           string `json:"picture,omitempty"`
+	UpdatedAt         int64  `json:"updated_at"`
+
+	Nonce string `json:"nonce,omitempty"`
+	Aud   string `json:"aud"`
+	Iss   string `json:"iss"`
+	Exp   int64  `json:"exp"`
+	Iat   int64  `json:"iat"`
+
+	jwt.RegisteredClaims
+}
+
+// UserInfoClaims builds the OIDC-standard claim set for a user row, shared
+// by both /auth/userinfo and BuildIDToken so the two payloads never drift
+// apart.
+func UserInfoClaims(user models.User) IDTokenClaims {
+	return IDTokenClaims{
+		Sub:               fmt.Sprintf("%d", user.ID),
+		Email:             user.Email,
+		PreferredUsername: user.Username,
+		GivenName:         user.FirstName,
+		FamilyName:        user.LastName,
+		Name:              fmt.Sprintf("%s %s", user.FirstName, user.LastName),
+		Picture:           user.Picture,
+		UpdatedAt:         user.UpdatedAt.Unix(),
+	}
+}
+
+// BuildIDToken mints a signed OIDC ID token for user, scoped to audience and
+// echoing back nonce (when the authorization request supplied one) so the
+// caller can detect replay.
+func (a *AuthService) BuildIDToken(user models.User, nonce, audience string) (string, error) {
+	now := time.Now()
+	claims := UserInfoClaims(user)
+	claims.Nonce = nonce
+	claims.Aud = audience
+	claims.Iss = a.config.JWT.Issuer
+	claims.Iat = now.Unix()
+	claims.Exp = now.Add(time.Duration(a.config.JWT.ExpiryHours) * time.Hour).Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(a.config.JWT.Secret))
+}
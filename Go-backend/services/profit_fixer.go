@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+// ProfitFixer rebuilds a user's positions and P&L from the authoritative
+// SnapTrade transaction history over a window, rather than trusting
+// locally-recorded Trade rows. It's a correctness backstop: if an import
+// ever drifted (a missed webhook, a manual edit, ...), re-running it over
+// the full history recomputes the truth straight from the broker feed.
+// This is a different concern from TradeReconciler (chunk2-7), which
+// incrementally auto-closes individual Trade rows as new fills arrive -
+// ProfitFixer never touches the trades table at all, only the aggregate
+// stats derived from it.
+//
+// Like TradeReconciler, it reads the shared "activities"/"holdings"
+// tables snaptrade-service's sync pipeline keeps updated rather than
+// importing that module directly.
+type ProfitFixer struct {
+	db *DatabaseService
+}
+
+// NewProfitFixer builds a ProfitFixer.
+func NewProfitFixer(db *DatabaseService) *ProfitFixer {
+	return &ProfitFixer{db: db}
+}
+
+// Position is a user's average-cost position in a single symbol, as
+// rebuilt from transaction history.
+type Position struct {
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`
+	AverageCost float64 `json:"average_cost"`
+}
+
+// SymbolProfitStats is the realized/unrealized P&L breakdown for a single
+// symbol.
+type SymbolProfitStats struct {
+	UserID        int64   `json:"user_id" db:"user_id"`
+	Symbol        string  `json:"symbol" db:"symbol"`
+	RealizedPnL   float64 `json:"realized_pnl" db:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl" db:"unrealized_pnl"`
+	ClosedTrades  int     `json:"closed_trades" db:"closed_trades"`
+	Wins          int     `json:"wins" db:"wins"`
+}
+
+// ProfitStats is a user's rebuilt P&L summary over the reconciled window.
+// Positions and BySymbol are returned to the caller but aren't part of the
+// profit_stats row itself - see profitStatsRow for what's actually stored.
+type ProfitStats struct {
+	UserID        int64               `json:"user_id"`
+	Since         time.Time           `json:"since"`
+	Until         time.Time           `json:"until"`
+	RealizedPnL   float64             `json:"realized_pnl"`
+	UnrealizedPnL float64             `json:"unrealized_pnl"`
+	WinRate       float64             `json:"win_rate"`
+	ClosedTrades  int                 `json:"closed_trades"`
+	Positions     []Position          `json:"positions"`
+	BySymbol      []SymbolProfitStats `json:"by_symbol"`
+	ComputedAt    time.Time           `json:"computed_at"`
+}
+
+// profitStatsRow is the subset of ProfitStats persisted to the
+// profit_stats table - Positions/BySymbol are derived and stored
+// separately (the latter in profit_stats_by_symbol).
+type profitStatsRow struct {
+	UserID        int64     `json:"user_id" db:"user_id"`
+	Since         time.Time `json:"since" db:"since"`
+	Until         time.Time `json:"until" db:"until"`
+	RealizedPnL   float64   `json:"realized_pnl" db:"realized_pnl"`
+	UnrealizedPnL float64   `json:"unrealized_pnl" db:"unrealized_pnl"`
+	WinRate       float64   `json:"win_rate" db:"win_rate"`
+	ClosedTrades  int       `json:"closed_trades" db:"closed_trades"`
+	ComputedAt    time.Time `json:"computed_at" db:"computed_at"`
+}
+
+// ProfitFixerResult is the response for POST /trades/reconcile/full: the
+// freshly rebuilt stats, plus whatever was stored before the run (nil if
+// this is the first run) so a dryRun caller can see what would change.
+type ProfitFixerResult struct {
+	Stats    ProfitStats  `json:"stats"`
+	Previous *ProfitStats `json:"previous,omitempty"`
+	DryRun   bool         `json:"dry_run"`
+}
+
+// Rebuild replays every transaction for userID's linked accounts in
+// [since, until], ascending by trade date, through an average-cost
+// position engine, and returns the resulting positions and P&L. Unless
+// dryRun is set, the result is also upserted into profit_stats /
+// profit_stats_by_symbol so GetTradingSummary/GetPerformanceMetrics can
+// be backed by it later.
+func (f *ProfitFixer) Rebuild(ctx context.Context, userID int64, since, until time.Time, dryRun bool) (*ProfitFixerResult, error) {
+	accounts, err := f.accountsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve accounts: %w", err)
+	}
+
+	activities, err := f.fetchActivities(ctx, accounts, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].TradeDate < activities[j].TradeDate
+	})
+
+	stats, positions := replay(activities)
+
+	if err := f.applyUnrealized(ctx, positions, stats); err != nil {
+		return nil, fmt.Errorf("failed to price open positions: %w", err)
+	}
+
+	summary := summarize(userID, since, until, stats)
+	summary.Positions = positions
+
+	result := &ProfitFixerResult{Stats: summary, DryRun: dryRun}
+
+	previous, err := f.loadPrevious(ctx, userID)
+	if err == nil {
+		result.Previous = previous
+	}
+
+	if !dryRun {
+		if err := f.store(ctx, summary, stats); err != nil {
+			return nil, fmt.Errorf("failed to store rebuilt stats: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+// FullReconcileHandler handles POST /trades/reconcile/full: rebuilds a
+// user's positions and P&L from the authoritative SnapTrade transaction
+// history over ?since=&until= (RFC3339, defaulting to the last 90 days),
+// optionally as a dry run via ?dryRun=true.
+func (f *ProfitFixer) FullReconcileHandler(c *fiber.Ctx) error {
+	userID, err := userIDFromLocals(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -90)
+
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid since"})
+		}
+		since = parsed
+	}
+	if raw := c.Query("until"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid until"})
+		}
+		until = parsed
+	}
+	dryRun := c.Query("dryRun") == "true"
+
+	result, err := f.Rebuild(c.Context(), userID, since, until, dryRun)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(result)
+}
+
+// userIDFromLocals mirrors SupabaseTradeService.getUserIDFromContext.
+func userIDFromLocals(c *fiber.Ctx) (int64, error) {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok || userIDStr == "" {
+		return 0, fmt.Errorf("missing userID in context")
+	}
+	return strconv.ParseInt(userIDStr, 10, 64)
+}
+
+// accountRow is the subset of the shared accounts table needed here.
+type accountRow struct {
+	AccountID string `json:"account_id"`
+	UserID    string `json:"user_id"`
+}
+
+func (f *ProfitFixer) accountsForUser(ctx context.Context, userID int64) ([]accountRow, error) {
+	var rows []accountRow
+	if err := f.db.Select(ctx, "accounts", "account_id,user_id", map[string]interface{}{
+		"user_id": fmt.Sprintf("%d", userID),
+	}, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// activityRow mirrors snaptrade-service's StoredActivity row shape (see
+// TradeReconciler's storedActivity for the same cross-module rationale).
+type activityRow struct {
+	ActivityID string  `json:"activity_id"`
+	AccountID  string  `json:"account_id"`
+	Type       string  `json:"type"`
+	Symbol     string  `json:"symbol"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"`
+	TradeDate  string  `json:"trade_date"`
+}
+
+// fetchActivities batch-queries transactions per account in parallel,
+// since each account is an independent Supabase read with no shared
+// state - an errgroup lets a slow or failing account not block the rest.
+func (f *ProfitFixer) fetchActivities(ctx context.Context, accounts []accountRow, since, until time.Time) ([]activityRow, error) {
+	results := make([][]activityRow, len(accounts))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, account := range accounts {
+		i, account := i, account
+		g.Go(func() error {
+			var rows []activityRow
+			opts := SelectOptions{
+				Eq:  map[string]interface{}{"account_id": account.AccountID},
+				Gte: map[string]interface{}{"trade_date": since.Format("2006-01-02")},
+				Lte: map[string]interface{}{"trade_date": until.Format("2006-01-02")},
+			}
+			if err := f.db.SelectWithOptions(gctx, "activities", "*", opts, &rows); err != nil {
+				return fmt.Errorf("account %s: %w", account.AccountID, err)
+			}
+			results[i] = rows
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var all []activityRow
+	for _, rows := range results {
+		all = append(all, rows...)
+	}
+	return all, nil
+}
+
+// replaySymbolState is the running average-cost position and accumulated
+// stats for one symbol during replay.
+type replaySymbolState struct {
+	position Position
+	stats    SymbolProfitStats
+}
+
+// replay runs activities (already sorted ascending by trade date) through
+// an average-cost position engine: buys blend into the running average
+// cost, sells realize P&L against it. Returns the accumulated per-symbol
+// stats and the resulting open positions.
+func replay(activities []activityRow) (map[string]*replaySymbolState, []Position) {
+	bySymbol := make(map[string]*replaySymbolState)
+
+	for _, act := range activities {
+		state, ok := bySymbol[act.Symbol]
+		if !ok {
+			state = &replaySymbolState{
+				position: Position{Symbol: act.Symbol},
+				stats:    SymbolProfitStats{Symbol: act.Symbol},
+			}
+			bySymbol[act.Symbol] = state
+		}
+
+		switch act.Type {
+		case "BUY", "buy":
+			totalCost := state.position.Quantity*state.position.AverageCost + act.Quantity*act.Price
+			state.position.Quantity += act.Quantity
+			if state.position.Quantity > 0 {
+				state.position.AverageCost = totalCost / state.position.Quantity
+			}
+		case "SELL", "sell":
+			qty := act.Quantity
+			if qty > state.position.Quantity {
+				qty = state.position.Quantity
+			}
+			pnl := (act.Price - state.position.AverageCost) * qty
+			state.stats.RealizedPnL += pnl
+			state.stats.ClosedTrades++
+			if pnl > 0 {
+				state.stats.Wins++
+			}
+			state.position.Quantity -= qty
+		}
+	}
+
+	positions := make([]Position, 0, len(bySymbol))
+	for _, state := range bySymbol {
+		if state.position.Quantity > 1e-9 {
+			positions = append(positions, state.position)
+		}
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+
+	return bySymbol, positions
+}
+
+// applyUnrealized marks-to-market every open position using the latest
+// cached holding price for its symbol.
+func (f *ProfitFixer) applyUnrealized(ctx context.Context, positions []Position, stats map[string]*replaySymbolState) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, len(positions))
+	for i, p := range positions {
+		symbols[i] = p.Symbol
+	}
+
+	var holdings []struct {
+		Symbol string  `json:"symbol"`
+		Price  float64 `json:"price"`
+	}
+	opts := SelectOptions{In: map[string][]string{"symbol": symbols}}
+	if err := f.db.SelectWithOptions(ctx, "holdings", "symbol,price", opts, &holdings); err != nil {
+		return err
+	}
+
+	latestPrice := make(map[string]float64, len(holdings))
+	for _, h := range holdings {
+		latestPrice[h.Symbol] = h.Price
+	}
+
+	for _, p := range positions {
+		price, ok := latestPrice[p.Symbol]
+		if !ok {
+			continue
+		}
+		stats[p.Symbol].stats.UnrealizedPnL = (price - p.AverageCost) * p.Quantity
+	}
+
+	return nil
+}
+
+// summarize rolls the per-symbol replay state up into the top-level
+// ProfitStats the caller sees.
+func summarize(userID int64, since, until time.Time, stats map[string]*replaySymbolState) ProfitStats {
+	summary := ProfitStats{
+		UserID:     userID,
+		Since:      since,
+		Until:      until,
+		ComputedAt: time.Now(),
+	}
+
+	var wins int
+	for _, state := range stats {
+		summary.RealizedPnL += state.stats.RealizedPnL
+		summary.UnrealizedPnL += state.stats.UnrealizedPnL
+		summary.ClosedTrades += state.stats.ClosedTrades
+		wins += state.stats.Wins
+		summary.BySymbol = append(summary.BySymbol, state.stats)
+	}
+	sort.Slice(summary.BySymbol, func(i, j int) bool { return summary.BySymbol[i].Symbol < summary.BySymbol[j].Symbol })
+
+	if summary.ClosedTrades > 0 {
+		summary.WinRate = float64(wins) / float64(summary.ClosedTrades)
+	}
+
+	return summary
+}
+
+func (f *ProfitFixer) loadPrevious(ctx context.Context, userID int64) (*ProfitStats, error) {
+	var rows []profitStatsRow
+	if err := f.db.Select(ctx, "profit_stats", "*", map[string]interface{}{"user_id": userID}, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no previous stats")
+	}
+
+	row := rows[0]
+	previous := ProfitStats{
+		UserID:        row.UserID,
+		Since:         row.Since,
+		Until:         row.Until,
+		RealizedPnL:   row.RealizedPnL,
+		UnrealizedPnL: row.UnrealizedPnL,
+		WinRate:       row.WinRate,
+		ClosedTrades:  row.ClosedTrades,
+		ComputedAt:    row.ComputedAt,
+	}
+
+	var bySymbol []SymbolProfitStats
+	if err := f.db.Select(ctx, "profit_stats_by_symbol", "*", map[string]interface{}{"user_id": userID}, &bySymbol); err == nil {
+		previous.BySymbol = bySymbol
+	}
+
+	return &previous, nil
+}
+
+// store atomically upserts the summary row and every per-symbol row. Both
+// upserts target the same userID, so a failure partway through still
+// leaves the prior run's rows intact rather than a mix of old and new.
+func (f *ProfitFixer) store(ctx context.Context, summary ProfitStats, stats map[string]*replaySymbolState) error {
+	row := profitStatsRow{
+		UserID:        summary.UserID,
+		Since:         summary.Since,
+		Until:         summary.Until,
+		RealizedPnL:   summary.RealizedPnL,
+		UnrealizedPnL: summary.UnrealizedPnL,
+		WinRate:       summary.WinRate,
+		ClosedTrades:  summary.ClosedTrades,
+		ComputedAt:    summary.ComputedAt,
+	}
+	if err := f.db.Upsert(ctx, "profit_stats", row, "user_id"); err != nil {
+		return err
+	}
+
+	if len(stats) == 0 {
+		return nil
+	}
+
+	rows := make([]SymbolProfitStats, 0, len(stats))
+	for _, state := range stats {
+		row := state.stats
+		row.UserID = summary.UserID
+		rows = append(rows, row)
+	}
+	return f.db.Upsert(ctx, "profit_stats_by_symbol", rows, "user_id,symbol")
+}
@@ -2,14 +2,23 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Johnson-f/tradistry_backend/models"
 	"github.com/gofiber/fiber/v2"
 )
 
+// defaultTradeListLimit and maxTradeListLimit bound GetTradesHandler's page
+// size when the caller doesn't specify (or over-specifies) ?limit=.
+const (
+	defaultTradeListLimit = 50
+	maxTradeListLimit     = 500
+)
+
 // SupabaseTradeService handles trade operations using Supabase
 type SupabaseTradeService struct {
 	db *DatabaseService
@@ -25,7 +34,7 @@ func NewSupabaseTradeService(db *DatabaseService) *SupabaseTradeService {
 // CreateTrade creates a new trade in Supabase
 func (s *SupabaseTradeService) CreateTrade(ctx context.Context, userID int64, req models.TradeCreateRequest) (*models.Trade, error) {
 	now := time.Now()
-	
+
 	trade := models.Trade{
 		UserID:     userID,
 		Symbol:     req.Symbol,
@@ -53,7 +62,7 @@ func (s *SupabaseTradeService) CreateTrade(ctx context.Context, userID int64, re
 		"symbol":     req.Symbol,
 		"created_at": now.Format(time.RFC3339),
 	}
-	
+
 	err = s.db.Select(ctx, "trades", "*", filters, &createdTrades)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch created trade: %w", err)
@@ -66,6 +75,81 @@ func (s *SupabaseTradeService) CreateTrade(ctx context.Context, userID int64, re
 	return &createdTrades[0], nil
 }
 
+// BulkCreateTrades imports a batch of fills from an already-authoritative
+// external ledger (e.g. a broker export) in a single round trip via
+// DatabaseService.InsertMany, rather than running CreateTrade's
+// insert-then-select once per row. Rows that fail basic validation are
+// skipped and reported back; there is no PnL recomputation and no re-select
+// of the inserted rows, since the caller already holds the authoritative
+// record and only needs to know what landed.
+func (s *SupabaseTradeService) BulkCreateTrades(ctx context.Context, userID int64, reqs []models.TradeCreateRequest) (*models.TradeBulkImportResult, error) {
+	now := time.Now()
+
+	rows := make([]models.Trade, 0, len(reqs))
+	rejected := make([]models.TradeBulkImportRejection, 0)
+
+	for i, req := range reqs {
+		if err := validateTradeCreateRequest(req); err != nil {
+			rejected = append(rejected, models.TradeBulkImportRejection{
+				Index:    i,
+				ClientID: req.ClientID,
+				Reason:   err.Error(),
+			})
+			continue
+		}
+
+		rows = append(rows, models.Trade{
+			UserID:     userID,
+			Symbol:     req.Symbol,
+			Type:       req.Type,
+			Status:     models.TradeOpen,
+			Quantity:   req.Quantity,
+			EntryPrice: req.EntryPrice,
+			StopLoss:   req.StopLoss,
+			TakeProfit: req.TakeProfit,
+			Notes:      req.Notes,
+			EntryDate:  req.EntryDate,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+	}
+
+	if len(rows) == 0 {
+		return &models.TradeBulkImportResult{Inserted: 0, Rejected: rejected}, nil
+	}
+
+	var inserted []models.Trade
+	if err := s.db.InsertMany(ctx, "trades", rows, &inserted); err != nil {
+		return nil, fmt.Errorf("failed to bulk import trades: %w", err)
+	}
+
+	return &models.TradeBulkImportResult{Inserted: len(inserted), Rejected: rejected}, nil
+}
+
+// validateTradeCreateRequest applies the same required/gt-zero checks
+// TradeCreateRequest's tags describe, by hand - this codebase doesn't wire
+// a struct-tag validator into the request path, so the bulk importer
+// checks the fields it cares about directly instead of trusting malformed
+// broker export rows.
+func validateTradeCreateRequest(req models.TradeCreateRequest) error {
+	if req.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if req.Type != models.TradeBuy && req.Type != models.TradeSell {
+		return fmt.Errorf("type must be %q or %q", models.TradeBuy, models.TradeSell)
+	}
+	if req.Quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than 0")
+	}
+	if req.EntryPrice <= 0 {
+		return fmt.Errorf("entry_price must be greater than 0")
+	}
+	if req.EntryDate.IsZero() {
+		return fmt.Errorf("entry_date is required")
+	}
+	return nil
+}
+
 // GetTradeByID retrieves a trade by ID
 func (s *SupabaseTradeService) GetTradeByID(ctx context.Context, userID, tradeID int64) (*models.Trade, error) {
 	var trades []models.Trade
@@ -182,20 +266,132 @@ func (s *SupabaseTradeService) DeleteTrade(ctx context.Context, userID, tradeID
 	return nil
 }
 
-// GetTradesByStatus retrieves trades by status for a user
+// GetTradesByStatus retrieves trades by status for a user. It's a thin
+// wrapper over ListUserTrades for callers that only need a status filter.
 func (s *SupabaseTradeService) GetTradesByStatus(ctx context.Context, userID int64, status models.TradeStatus) ([]models.Trade, error) {
+	result, err := s.ListUserTrades(ctx, userID, models.TradeListQuery{Status: status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trades by status: %w", err)
+	}
+	return result.Data, nil
+}
+
+// ListUserTrades is the filtered, sorted, keyset-paginated counterpart to
+// GetUserTrades: it backs GetTradesHandler so a user with thousands of
+// imported trades isn't served in one unbounded row set.
+func (s *SupabaseTradeService) ListUserTrades(ctx context.Context, userID int64, q models.TradeListQuery) (*models.TradeListResult, error) {
+	opts := SelectOptions{
+		Eq:  map[string]interface{}{"user_id": userID},
+		Gte: map[string]interface{}{},
+		Lte: map[string]interface{}{},
+	}
+
+	if q.Symbol != "" {
+		opts.Eq["symbol"] = q.Symbol
+	}
+	if q.Status != "" {
+		opts.Eq["status"] = q.Status
+	}
+	if q.Type != "" {
+		opts.Eq["type"] = q.Type
+	}
+	if q.From != nil {
+		opts.Gte["exit_date"] = q.From.Format(time.RFC3339)
+	}
+	if q.To != nil {
+		opts.Lte["exit_date"] = q.To.Format(time.RFC3339)
+	}
+	if q.MinPnL != nil {
+		opts.Gte["pnl"] = *q.MinPnL
+	}
+	if q.MaxPnL != nil {
+		opts.Lte["pnl"] = *q.MaxPnL
+	}
+
+	opts.OrderColumn = q.SortColumn
+	if opts.OrderColumn == "" {
+		opts.OrderColumn = "exit_date"
+	}
+	opts.OrderDesc = q.SortDesc
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultTradeListLimit
+	}
+	if limit > maxTradeListLimit {
+		limit = maxTradeListLimit
+	}
+	// Fetch one extra row so we can tell whether there's a next page
+	// without a separate count query.
+	opts.Limit = limit + 1
+
+	if q.Cursor != "" {
+		orderValue, afterID, err := decodeTradeCursor(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		opts.AfterOrderValue = orderValue
+		opts.AfterID = afterID
+	}
+
 	var trades []models.Trade
-	filters := map[string]interface{}{
-		"user_id": userID,
-		"status":  status,
+	if err := s.db.SelectWithOptions(ctx, "trades", "*", opts, &trades); err != nil {
+		return nil, fmt.Errorf("failed to list trades: %w", err)
 	}
 
-	err := s.db.Select(ctx, "trades", "*", filters, &trades)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get trades by status: %w", err)
+	result := &models.TradeListResult{Data: trades}
+	if len(trades) > limit {
+		result.Data = trades[:limit]
+		last := result.Data[len(result.Data)-1]
+		result.NextCursor = encodeTradeCursor(orderColumnValue(last, opts.OrderColumn), last.ID)
 	}
 
-	return trades, nil
+	return result, nil
+}
+
+// orderColumnValue reads the field ListUserTrades ordered by off t, so the
+// keyset cursor advances on whatever column the caller sorted by.
+func orderColumnValue(t models.Trade, column string) string {
+	switch column {
+	case "entry_date":
+		return t.EntryDate.Format(time.RFC3339)
+	case "pnl":
+		if t.PnL != nil {
+			return fmt.Sprintf("%v", *t.PnL)
+		}
+		return ""
+	case "exit_date":
+		fallthrough
+	default:
+		if t.ExitDate != nil {
+			return t.ExitDate.Format(time.RFC3339)
+		}
+		return ""
+	}
+}
+
+// encodeTradeCursor/decodeTradeCursor pack the keyset pagination cursor
+// (the sort column's value plus the row id, as a tiebreaker) into an
+// opaque, URL-safe token so clients don't need to know its internal shape.
+func encodeTradeCursor(orderValue string, id int64) string {
+	raw := fmt.Sprintf("%s|%d", orderValue, id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTradeCursor(cursor string) (orderValue string, id int64, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, err
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("malformed cursor")
+	}
+	id, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed cursor id: %w", err)
+	}
+	return parts[0], id, nil
 }
 
 // getUserIDFromContext extracts user ID from fiber context
@@ -204,13 +400,13 @@ func (s *SupabaseTradeService) getUserIDFromContext(c *fiber.Ctx) (int64, error)
 	if !ok || userIDStr == "" {
 		return 0, fmt.Errorf("user ID not found in context")
 	}
-	
+
 	// Convert string user ID to int64
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
 	if err != nil {
 		return 0, fmt.Errorf("invalid user ID format: %w", err)
 	}
-	
+
 	return userID, nil
 }
 
@@ -243,7 +439,41 @@ func (s *SupabaseTradeService) CreateTradeHandler(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(trade)
 }
 
-// GetTradesHandler handles GET /trades
+// BulkImportTradesHandler handles POST /trades/bulk
+func (s *SupabaseTradeService) BulkImportTradesHandler(c *fiber.Ctx) error {
+	var req models.TradeBulkImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Trades) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "trades is required and must contain at least one row",
+		})
+	}
+
+	userID, err := s.getUserIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	result, err := s.BulkCreateTrades(c.Context(), userID, req.Trades)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// GetTradesHandler handles GET /trades?symbol=&status=&type=&from=&to=&
+// min_pnl=&max_pnl=&sort=exit_date:desc&limit=&cursor=, returning a page of
+// the user's trades matching those filters.
 func (s *SupabaseTradeService) GetTradesHandler(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
 	userID, err := s.getUserIDFromContext(c)
@@ -253,14 +483,79 @@ func (s *SupabaseTradeService) GetTradesHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	trades, err := s.GetUserTrades(c.Context(), userID)
+	q, err := parseTradeListQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	result, err := s.ListUserTrades(c.Context(), userID, q)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	return c.JSON(trades)
+	return c.JSON(result)
+}
+
+// parseTradeListQuery parses GetTradesHandler's query string into a
+// models.TradeListQuery, returning an error when a filter value can't be
+// parsed (e.g. a non-numeric min_pnl) rather than silently ignoring it.
+func parseTradeListQuery(c *fiber.Ctx) (models.TradeListQuery, error) {
+	q := models.TradeListQuery{
+		Symbol: c.Query("symbol"),
+		Status: models.TradeStatus(c.Query("status")),
+		Type:   models.TradeType(c.Query("type")),
+		Limit:  defaultTradeListLimit,
+		Cursor: c.Query("cursor"),
+	}
+
+	if raw := c.Query("from"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid from: %w", err)
+		}
+		q.From = &t
+	}
+	if raw := c.Query("to"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid to: %w", err)
+		}
+		q.To = &t
+	}
+	if raw := c.Query("min_pnl"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid min_pnl: %w", err)
+		}
+		q.MinPnL = &v
+	}
+	if raw := c.Query("max_pnl"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return q, fmt.Errorf("invalid max_pnl: %w", err)
+		}
+		q.MaxPnL = &v
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		column, dir, _ := strings.Cut(raw, ":")
+		q.SortColumn = column
+		q.SortDesc = dir == "desc"
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid limit: %w", err)
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
 }
 
 // GetTradeHandler handles GET /trades/:id
@@ -383,55 +678,26 @@ func (s *SupabaseTradeService) CloseTrade(c *fiber.Ctx) error {
 	return c.JSON(trade)
 }
 
-// GetTradingSummary handles getting trading summary analytics (placeholder implementation)
+// GetTradingSummary returns the full quantitative trading summary - equity
+// curve, drawdown, Sharpe/Sortino, profit factor, expectancy, streaks, and
+// the R-multiple distribution - computed from the user's closed trades.
+// GetPerformanceMetrics is an alias of this over the same computation; the
+// two routes are kept separate for API compatibility with existing callers.
 func (s *SupabaseTradeService) GetTradingSummary(c *fiber.Ctx) error {
-	// Get user ID from context (set by auth middleware)
-	userID, err := s.getUserIDFromContext(c)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Authentication required",
-		})
-	}
-
-	trades, err := s.GetUserTrades(c.Context(), userID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
-	}
-
-	// Calculate basic summary
-	totalTrades := len(trades)
-	var totalPnL float64
-	profitableTrades := 0
-
-	for _, trade := range trades {
-		if trade.PnL != nil {
-			totalPnL += *trade.PnL
-			if *trade.PnL > 0 {
-				profitableTrades++
-			}
-		}
-	}
-
-	winRate := float64(0)
-	if totalTrades > 0 {
-		winRate = float64(profitableTrades) / float64(totalTrades) * 100
-	}
-
-	summary := fiber.Map{
-		"total_trades":      totalTrades,
-		"profitable_trades": profitableTrades,
-		"total_pnl":         totalPnL,
-		"win_rate":          winRate,
-	}
-
-	return c.JSON(summary)
+	return s.tradingAnalyticsHandler(c)
 }
 
-// GetPerformanceMetrics handles getting performance metrics (placeholder implementation)
+// GetPerformanceMetrics handles getting performance metrics. See
+// GetTradingSummary.
 func (s *SupabaseTradeService) GetPerformanceMetrics(c *fiber.Ctx) error {
-	// Get user ID from context (set by auth middleware)
+	return s.tradingAnalyticsHandler(c)
+}
+
+// tradingAnalyticsHandler backs both GetTradingSummary and
+// GetPerformanceMetrics. periods_per_year lets the caller annualize
+// Sharpe/Sortino for their trading cadence (e.g. 252 for daily, 52 for
+// weekly); it defaults to 252.
+func (s *SupabaseTradeService) tradingAnalyticsHandler(c *fiber.Ctx) error {
 	userID, err := s.getUserIDFromContext(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -446,39 +712,12 @@ func (s *SupabaseTradeService) GetPerformanceMetrics(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate basic performance metrics
-	totalTrades := len(trades)
-	var totalPnL, maxDrawdown, maxProfit float64
-	profitableTrades := 0
-
-	for _, trade := range trades {
-		if trade.PnL != nil {
-			pnl := *trade.PnL
-			totalPnL += pnl
-			if pnl > 0 {
-				profitableTrades++
-				if pnl > maxProfit {
-					maxProfit = pnl
-				}
-			} else if pnl < maxDrawdown {
-				maxDrawdown = pnl
-			}
+	periodsPerYear := defaultPeriodsPerYear
+	if raw := c.Query("periods_per_year"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 {
+			periodsPerYear = v
 		}
 	}
 
-	avgPnL := float64(0)
-	if totalTrades > 0 {
-		avgPnL = totalPnL / float64(totalTrades)
-	}
-
-	metrics := fiber.Map{
-		"total_trades":      totalTrades,
-		"profitable_trades": profitableTrades,
-		"total_pnl":         totalPnL,
-		"average_pnl":       avgPnL,
-		"max_profit":        maxProfit,
-		"max_drawdown":      maxDrawdown,
-	}
-
-	return c.JSON(metrics)
+	return c.JSON(computeTradingAnalytics(trades, periodsPerYear))
 }
@@ -12,16 +12,32 @@ import (
 
 // SupabaseUserService handles user operations using Supabase
 type SupabaseUserService struct {
-	db *DatabaseService
+	db      *DatabaseService
+	events  *ActionEventRepository
+	apiKeys *ApiKeyRepository
 }
 
 // NewSupabaseUserService creates a new Supabase user service
 func NewSupabaseUserService(db *DatabaseService) *SupabaseUserService {
 	return &SupabaseUserService{
-		db: db,
+		db:      db,
+		events:  NewActionEventRepository(db),
+		apiKeys: NewApiKeyRepository(db),
 	}
 }
 
+// recordEvent writes a best-effort action_events row. A failure here
+// shouldn't fail the user operation it's instrumenting, so the error is
+// dropped.
+func (s *SupabaseUserService) recordEvent(ctx context.Context, accountID int64, action, target string) {
+	s.events.Record(ctx, models.ActionEvent{
+		AccountID: accountID,
+		Action:    action,
+		Target:    target,
+		CreatedAt: time.Now(),
+	})
+}
+
 // CreateUser creates a new user in Supabase
 func (s *SupabaseUserService) CreateUser(ctx context.Context, req models.UserCreateRequest) (*models.User, error) {
 	now := time.Now()
@@ -57,6 +73,8 @@ func (s *SupabaseUserService) CreateUser(ctx context.Context, req models.UserCre
 		return nil, fmt.Errorf("user was created but not found")
 	}
 
+	s.recordEvent(ctx, createdUsers[0].ID, "user_created", "")
+
 	return &createdUsers[0], nil
 }
 
@@ -138,6 +156,8 @@ func (s *SupabaseUserService) UpdateUser(ctx context.Context, userID int64, upda
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
+	s.recordEvent(ctx, userID, "user_updated", "")
+
 	// Return updated user
 	return s.GetUserByID(ctx, userID)
 }
@@ -159,6 +179,8 @@ func (s *SupabaseUserService) DeleteUser(ctx context.Context, userID int64) erro
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
+	s.recordEvent(ctx, userID, "user_deleted", "")
+
 	return nil
 }
 
@@ -204,6 +226,45 @@ func (s *SupabaseUserService) UserExists(ctx context.Context, email, username st
 	return len(users) > 0, nil
 }
 
+// EnrollFactor persists a new MFA factor for a user.
+func (s *SupabaseUserService) EnrollFactor(ctx context.Context, factor models.AuthFactor) error {
+	err := s.db.Insert(ctx, "auth_factors", factor)
+	if err != nil {
+		return fmt.Errorf("failed to enroll auth factor: %w", err)
+	}
+	return nil
+}
+
+// ListFactors returns the MFA factors enrolled for a user.
+func (s *SupabaseUserService) ListFactors(ctx context.Context, userID int64) ([]models.AuthFactor, error) {
+	var factors []models.AuthFactor
+	filters := map[string]interface{}{
+		"user_id": userID,
+	}
+
+	err := s.db.Select(ctx, "auth_factors", "*", filters, &factors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list auth factors: %w", err)
+	}
+
+	return factors, nil
+}
+
+// DeleteFactor removes a single enrolled MFA factor.
+func (s *SupabaseUserService) DeleteFactor(ctx context.Context, userID int64, factorID string) error {
+	filters := map[string]interface{}{
+		"user_id": userID,
+		"id":      factorID,
+	}
+
+	err := s.db.Delete(ctx, "auth_factors", filters)
+	if err != nil {
+		return fmt.Errorf("failed to delete auth factor: %w", err)
+	}
+
+	return nil
+}
+
 // HTTP Handler Methods for Fiber
 
 // CreateUserHandler handles HTTP request for creating a user
@@ -356,3 +417,58 @@ func (s *SupabaseUserService) DeleteUserHandler(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
+
+// GetEventsHandler handles GET /users/:id/events?take=&offset=, returning a
+// page of the target user's action_events audit log. AuthMiddleware must run
+// first so c.Locals("userID")/"userRole" are populated; the caller may only
+// read their own timeline unless their JWT role is service_role.
+func (s *SupabaseUserService) GetEventsHandler(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	userID, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	callerRole, _ := c.Locals("userRole").(string)
+	callerID, _ := c.Locals("userID").(string)
+	if callerRole != "service_role" && callerID != idParam {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Not authorized to view this user's events",
+		})
+	}
+
+	take := 50
+	if takeParam := c.Query("take"); takeParam != "" {
+		if parsed, err := strconv.Atoi(takeParam); err == nil && parsed > 0 && parsed <= 200 {
+			take = parsed
+		}
+	}
+
+	offset := 0
+	if offsetParam := c.Query("offset"); offsetParam != "" {
+		if parsed, err := strconv.Atoi(offsetParam); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	events, err := s.events.ListByAccount(c.Context(), userID, take, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list events",
+		})
+	}
+
+	count, err := s.events.CountByAccount(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count events",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"count": count,
+		"data":  events,
+	})
+}
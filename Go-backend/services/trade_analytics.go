@@ -0,0 +1,242 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+)
+
+// defaultPeriodsPerYear annualizes Sharpe/Sortino assuming one trade closed
+// per trading day when the caller doesn't supply periods_per_year.
+const defaultPeriodsPerYear = 252.0
+
+// rMultipleBucketWidth and rMultipleBucketRange bound the R-multiple
+// histogram to [-rMultipleBucketRange, rMultipleBucketRange) in
+// rMultipleBucketWidth-wide bars, clamping anything further out into the
+// nearest edge bucket so a single outlier trade can't blow up the series.
+const (
+	rMultipleBucketWidth = 1.0
+	rMultipleBucketRange = 5.0
+)
+
+// EquityPoint is one point on the cumulative-PnL equity curve, in
+// close-time order.
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// RMultipleBucket is one bar of the R-multiple histogram: the count of
+// trades whose R-multiple fell in [Min, Max).
+type RMultipleBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// TradingAnalytics is the full quantitative summary computed from a user's
+// closed trades, ordered by ExitDate.
+type TradingAnalytics struct {
+	TotalTrades       int               `json:"total_trades"`
+	ProfitableTrades  int               `json:"profitable_trades"`
+	LosingTrades      int               `json:"losing_trades"`
+	WinRate           float64           `json:"win_rate"`
+	TotalPnL          float64           `json:"total_pnl"`
+	ProfitFactor      float64           `json:"profit_factor"`
+	Expectancy        float64           `json:"expectancy"`
+	AverageWin        float64           `json:"average_win"`
+	AverageLoss       float64           `json:"average_loss"`
+	LongestWinStreak  int               `json:"longest_win_streak"`
+	LongestLoseStreak int               `json:"longest_lose_streak"`
+	MaxDrawdown       float64           `json:"max_drawdown"`
+	PeriodsPerYear    float64           `json:"periods_per_year"`
+	Sharpe            float64           `json:"sharpe"`
+	Sortino           float64           `json:"sortino"`
+	EquityCurve       []EquityPoint     `json:"equity_curve"`
+	RMultiples        []RMultipleBucket `json:"r_multiple_histogram"`
+}
+
+// computeTradingAnalytics derives TradingAnalytics from trades. Only
+// closed trades with both PnL and ExitDate populated contribute - open
+// trades have no realized return yet. periodsPerYear annualizes Sharpe
+// and Sortino; pass 252 for one trade/day, 52 for one/week, etc.
+func computeTradingAnalytics(trades []models.Trade, periodsPerYear float64) TradingAnalytics {
+	closed := make([]models.Trade, 0, len(trades))
+	for _, t := range trades {
+		if t.PnL != nil && t.ExitDate != nil {
+			closed = append(closed, t)
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		return closed[i].ExitDate.Before(*closed[j].ExitDate)
+	})
+
+	a := TradingAnalytics{
+		TotalTrades:    len(closed),
+		PeriodsPerYear: periodsPerYear,
+	}
+	if len(closed) == 0 {
+		return a
+	}
+
+	var (
+		sumWins, sumLosses float64
+		returns            []float64
+		winStreak          int
+		loseStreak         int
+		equity             float64
+		peak               float64
+	)
+
+	for i, t := range closed {
+		pnl := *t.PnL
+		a.TotalPnL += pnl
+
+		if pnl > 0 {
+			a.ProfitableTrades++
+			sumWins += pnl
+			winStreak++
+			loseStreak = 0
+		} else if pnl < 0 {
+			a.LosingTrades++
+			sumLosses += pnl
+			loseStreak++
+			winStreak = 0
+		} else {
+			winStreak, loseStreak = 0, 0
+		}
+		if winStreak > a.LongestWinStreak {
+			a.LongestWinStreak = winStreak
+		}
+		if loseStreak > a.LongestLoseStreak {
+			a.LongestLoseStreak = loseStreak
+		}
+
+		if basis := t.EntryPrice * t.Quantity; basis != 0 {
+			returns = append(returns, pnl/basis)
+		}
+
+		equity += pnl
+		if i == 0 || equity > peak {
+			peak = equity
+		}
+		if peak != 0 {
+			if dd := (peak - equity) / peak; dd > a.MaxDrawdown {
+				a.MaxDrawdown = dd
+			}
+		}
+		a.EquityCurve = append(a.EquityCurve, EquityPoint{Time: *t.ExitDate, Equity: equity})
+	}
+
+	a.WinRate = float64(a.ProfitableTrades) / float64(a.TotalTrades) * 100
+	if sumLosses != 0 {
+		a.ProfitFactor = sumWins / math.Abs(sumLosses)
+	}
+	if a.ProfitableTrades > 0 {
+		a.AverageWin = sumWins / float64(a.ProfitableTrades)
+	}
+	if a.LosingTrades > 0 {
+		a.AverageLoss = sumLosses / float64(a.LosingTrades)
+	}
+	winRateFrac := a.WinRate / 100
+	a.Expectancy = winRateFrac*a.AverageWin + (1-winRateFrac)*a.AverageLoss
+
+	a.Sharpe = sharpeRatio(returns, periodsPerYear)
+	a.Sortino = sortinoRatio(returns, periodsPerYear)
+	a.RMultiples = rMultipleHistogram(closed)
+
+	return a
+}
+
+// sharpeRatio is mean(returns)/stddev(returns), annualized by
+// sqrt(periodsPerYear). It's 0 when there are fewer than two returns or
+// the returns have no variance (a flat or single-sample series has no
+// defined Sharpe).
+func sharpeRatio(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean, stddev := meanAndStddev(returns)
+	if stddev == 0 {
+		return 0
+	}
+	return mean / stddev * math.Sqrt(periodsPerYear)
+}
+
+// sortinoRatio mirrors sharpeRatio but divides by the standard deviation
+// of negative returns only, so upside volatility doesn't penalize the
+// ratio the way it does with Sharpe.
+func sortinoRatio(returns []float64, periodsPerYear float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	mean, _ := meanAndStddev(returns)
+
+	var downside []float64
+	for _, r := range returns {
+		if r < 0 {
+			downside = append(downside, r)
+		}
+	}
+	if len(downside) < 2 {
+		return 0
+	}
+	_, downsideDev := meanAndStddev(downside)
+	if downsideDev == 0 {
+		return 0
+	}
+	return mean / downsideDev * math.Sqrt(periodsPerYear)
+}
+
+// meanAndStddev returns the sample mean and sample standard deviation
+// (n-1 denominator) of values.
+func meanAndStddev(values []float64) (mean, stddev float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var sumSq float64
+	for _, v := range values {
+		d := v - mean
+		sumSq += d * d
+	}
+	stddev = math.Sqrt(sumSq / float64(len(values)-1))
+	return mean, stddev
+}
+
+// rMultipleHistogram buckets each trade's R-multiple - (exit-entry)/
+// (entry-stop_loss) - into fixed-width bars. Trades without a StopLoss
+// have no defined risk and are excluded.
+func rMultipleHistogram(trades []models.Trade) []RMultipleBucket {
+	numBuckets := int(2 * rMultipleBucketRange / rMultipleBucketWidth)
+	buckets := make([]RMultipleBucket, numBuckets)
+	for i := range buckets {
+		min := -rMultipleBucketRange + float64(i)*rMultipleBucketWidth
+		buckets[i] = RMultipleBucket{Min: min, Max: min + rMultipleBucketWidth}
+	}
+
+	for _, t := range trades {
+		if t.StopLoss == nil || t.ExitPrice == nil {
+			continue
+		}
+		risk := t.EntryPrice - *t.StopLoss
+		if risk == 0 {
+			continue
+		}
+		rMultiple := (*t.ExitPrice - t.EntryPrice) / risk
+
+		idx := int(math.Floor((rMultiple + rMultipleBucketRange) / rMultipleBucketWidth))
+		if idx < 0 {
+			idx = 0
+		}
+		if idx >= numBuckets {
+			idx = numBuckets - 1
+		}
+		buckets[idx].Count++
+	}
+
+	return buckets
+}
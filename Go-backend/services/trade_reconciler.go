@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Johnson-f/tradistry_backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CostModel selects which open lot(s) for a symbol a closing fill is
+// matched against when more than one Trade row is open at once.
+type CostModel string
+
+const (
+	// CostModelFIFO closes the oldest open lot first.
+	CostModelFIFO CostModel = "fifo"
+	// CostModelLIFO closes the most recently opened lot first.
+	CostModelLIFO CostModel = "lifo"
+	// CostModelAverageCost treats every open lot for a symbol as a single
+	// blended position, so a closing fill's PnL is computed against the
+	// quantity-weighted average entry price rather than any one lot's.
+	CostModelAverageCost CostModel = "average_cost"
+)
+
+// BrokerFill is one executed fill pulled from a brokerage activity feed.
+// ActivitySource implementations translate whatever shape their provider
+// returns (e.g. SnapTrade account activities) into this.
+type BrokerFill struct {
+	ExternalID string
+	Symbol     string
+	// Side is the fill's own direction - a "sell" fill closes a "buy"
+	// Trade and vice versa.
+	Side     models.TradeType
+	Quantity float64
+	Price    float64
+	FilledAt time.Time
+}
+
+// ActivitySource fetches fills for a user since a point in time.
+type ActivitySource interface {
+	FetchFills(ctx context.Context, userID int64, since time.Time) ([]BrokerFill, error)
+}
+
+// ReconciliationResult summarizes one ReconcileUser call.
+type ReconciliationResult struct {
+	ClosedTrades   int `json:"closed_trades"`
+	SplitTrades    int `json:"split_trades"`
+	UnmatchedFills int `json:"unmatched_fills"`
+}
+
+// TradeReconciler matches incoming broker fills against a user's open
+// Trade rows and auto-applies exit_price/exit_date/pnl/status, so the
+// user doesn't have to call UpdateTrade/CloseTrade by hand once their
+// broker reports the fill. Every match is also written to audit_trades
+// for traceability, independent of whatever the Trade row looks like by
+// the time someone investigates it later.
+type TradeReconciler struct {
+	db        *DatabaseService
+	trades    *SupabaseTradeService
+	source    ActivitySource
+	costModel CostModel
+}
+
+// NewTradeReconciler builds a TradeReconciler. costModel defaults to FIFO
+// when empty.
+func NewTradeReconciler(db *DatabaseService, trades *SupabaseTradeService, source ActivitySource, costModel CostModel) *TradeReconciler {
+	if costModel == "" {
+		costModel = CostModelFIFO
+	}
+	return &TradeReconciler{db: db, trades: trades, source: source, costModel: costModel}
+}
+
+// ReconcileUser pulls fills for userID since its oldest open trade's entry
+// date, matches closing fills against open lots per symbol (ordered per
+// the reconciler's cost model), and updates or splits the matched Trade
+// rows accordingly.
+func (r *TradeReconciler) ReconcileUser(ctx context.Context, userID int64) (*ReconciliationResult, error) {
+	openTrades, err := r.trades.GetTradesByStatus(ctx, userID, models.TradeOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load open trades: %w", err)
+	}
+	result := &ReconciliationResult{}
+	if len(openTrades) == 0 {
+		return result, nil
+	}
+
+	since := openTrades[0].EntryDate
+	for _, t := range openTrades {
+		if t.EntryDate.Before(since) {
+			since = t.EntryDate
+		}
+	}
+
+	fills, err := r.source.FetchFills(ctx, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fills: %w", err)
+	}
+
+	lotsBySymbol := make(map[string][]models.Trade)
+	for _, t := range openTrades {
+		lotsBySymbol[t.Symbol] = append(lotsBySymbol[t.Symbol], t)
+	}
+	for symbol := range lotsBySymbol {
+		sortLots(lotsBySymbol[symbol], r.costModel)
+	}
+
+	avgEntryBySymbol := make(map[string]float64)
+	if r.costModel == CostModelAverageCost {
+		for symbol, lots := range lotsBySymbol {
+			avgEntryBySymbol[symbol] = averageEntryPrice(lots)
+		}
+	}
+
+	for _, fill := range fills {
+		lots := lotsBySymbol[fill.Symbol]
+		remaining := fill.Quantity
+
+		for len(lots) > 0 && remaining > 1e-9 {
+			lot := lots[0]
+			if !closesLot(lot, fill.Side) {
+				lots = lots[1:]
+				continue
+			}
+
+			matched := math.Min(remaining, lot.Quantity)
+			entryPrice := lot.EntryPrice
+			if r.costModel == CostModelAverageCost {
+				entryPrice = avgEntryBySymbol[fill.Symbol]
+			}
+			pnl := lotPnL(lot.Type, entryPrice, fill.Price, matched)
+
+			if lot.Quantity-matched <= 1e-9 {
+				if err := r.closeTrade(ctx, lot, fill, entryPrice, matched, pnl); err != nil {
+					return nil, err
+				}
+				result.ClosedTrades++
+				lots = lots[1:]
+			} else {
+				if err := r.splitTrade(ctx, lot, fill, entryPrice, matched, pnl); err != nil {
+					return nil, err
+				}
+				result.SplitTrades++
+				lot.Quantity -= matched
+				lots[0] = lot
+			}
+
+			remaining -= matched
+		}
+
+		lotsBySymbol[fill.Symbol] = lots
+		if remaining > 1e-9 {
+			result.UnmatchedFills++
+		}
+	}
+
+	return result, nil
+}
+
+// ReconcileHandler handles POST /trades/reconcile: an on-demand
+// alternative to waiting for the next cron pass, for a user who wants
+// their trades caught up immediately after a broker sync.
+func (r *TradeReconciler) ReconcileHandler(c *fiber.Ctx) error {
+	userID, err := r.trades.getUserIDFromContext(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Authentication required",
+		})
+	}
+
+	result, err := r.ReconcileUser(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// RunLoop reconciles every user with at least one open trade on a fixed
+// interval, until ctx is cancelled. A single user's failure is logged and
+// skipped rather than aborting the whole pass.
+func (r *TradeReconciler) RunLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reconcileAllUsers(ctx)
+		}
+	}
+}
+
+func (r *TradeReconciler) reconcileAllUsers(ctx context.Context) {
+	var rows []struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err := r.db.Select(ctx, "trades", "user_id", map[string]interface{}{"status": models.TradeOpen}, &rows); err != nil {
+		return
+	}
+
+	seen := make(map[int64]bool)
+	for _, row := range rows {
+		if seen[row.UserID] {
+			continue
+		}
+		seen[row.UserID] = true
+		r.ReconcileUser(ctx, row.UserID)
+	}
+}
+
+// closeTrade applies a full close to lot and records the audit trail.
+func (r *TradeReconciler) closeTrade(ctx context.Context, lot models.Trade, fill BrokerFill, entryPrice float64, matched, pnl float64) error {
+	updates := map[string]interface{}{
+		"exit_price": fill.Price,
+		"exit_date":  fill.FilledAt,
+		"pnl":        pnl,
+		"status":     models.TradeClosed,
+		"updated_at": time.Now(),
+	}
+	if err := r.db.Update(ctx, "trades", updates, map[string]interface{}{"id": lot.ID, "user_id": lot.UserID}); err != nil {
+		return fmt.Errorf("failed to close trade %d: %w", lot.ID, err)
+	}
+	return r.recordAudit(ctx, lot, fill, "close", matched, pnl)
+}
+
+// splitTrade closes matched units of lot into a new Trade row and leaves
+// the remainder of lot open with a reduced quantity, for a fill that only
+// partially closes the position.
+func (r *TradeReconciler) splitTrade(ctx context.Context, lot models.Trade, fill BrokerFill, entryPrice float64, matched, pnl float64) error {
+	now := time.Now()
+	closedPortion := []models.Trade{{
+		UserID:     lot.UserID,
+		Symbol:     lot.Symbol,
+		Type:       lot.Type,
+		Status:     models.TradeClosed,
+		Quantity:   matched,
+		EntryPrice: lot.EntryPrice,
+		ExitPrice:  &fill.Price,
+		PnL:        &pnl,
+		Notes:      fmt.Sprintf("split from trade %d by reconciliation", lot.ID),
+		EntryDate:  lot.EntryDate,
+		ExitDate:   &fill.FilledAt,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}}
+
+	var inserted []models.Trade
+	if err := r.db.InsertMany(ctx, "trades", closedPortion, &inserted); err != nil {
+		return fmt.Errorf("failed to split trade %d: %w", lot.ID, err)
+	}
+
+	remainingQty := lot.Quantity - matched
+	if err := r.db.Update(ctx, "trades",
+		map[string]interface{}{"quantity": remainingQty, "updated_at": now},
+		map[string]interface{}{"id": lot.ID, "user_id": lot.UserID},
+	); err != nil {
+		return fmt.Errorf("failed to reduce trade %d after split: %w", lot.ID, err)
+	}
+
+	splitTrade := lot
+	if len(inserted) > 0 {
+		splitTrade = inserted[0]
+	}
+	return r.recordAudit(ctx, splitTrade, fill, "split_close", matched, pnl)
+}
+
+func (r *TradeReconciler) recordAudit(ctx context.Context, lot models.Trade, fill BrokerFill, action string, matched, pnl float64) error {
+	audit := models.AuditTrade{
+		TradeID:        lot.ID,
+		UserID:         lot.UserID,
+		Action:         action,
+		FillExternalID: fill.ExternalID,
+		MatchedQty:     matched,
+		ExitPrice:      fill.Price,
+		PnL:            pnl,
+		CostModel:      string(r.costModel),
+		CreatedAt:      time.Now(),
+	}
+	if err := r.db.Insert(ctx, "audit_trades", audit); err != nil {
+		return fmt.Errorf("failed to record audit trail for trade %d: %w", lot.ID, err)
+	}
+	return nil
+}
+
+// sortLots orders a symbol's open lots so the first element is the one
+// that should be closed first under model: oldest-first for FIFO,
+// newest-first for LIFO. AverageCost doesn't care about order since every
+// lot shares the same blended entry price, but FIFO order is still used
+// so partial fills consume lots deterministically.
+func sortLots(lots []models.Trade, model CostModel) {
+	sort.Slice(lots, func(i, j int) bool {
+		if model == CostModelLIFO {
+			return lots[i].EntryDate.After(lots[j].EntryDate)
+		}
+		return lots[i].EntryDate.Before(lots[j].EntryDate)
+	})
+}
+
+// averageEntryPrice is the quantity-weighted average entry price across
+// lots, used for CostModelAverageCost.
+func averageEntryPrice(lots []models.Trade) float64 {
+	var totalQty, totalCost float64
+	for _, l := range lots {
+		totalQty += l.Quantity
+		totalCost += l.Quantity * l.EntryPrice
+	}
+	if totalQty == 0 {
+		return 0
+	}
+	return totalCost / totalQty
+}
+
+// closesLot reports whether a fill on the given side closes (rather than
+// adds to) a lot: a sell fill closes a buy lot and a buy fill closes a
+// sell (short) lot.
+func closesLot(lot models.Trade, fillSide models.TradeType) bool {
+	switch lot.Type {
+	case models.TradeBuy:
+		return fillSide == models.TradeSell
+	case models.TradeSell:
+		return fillSide == models.TradeBuy
+	default:
+		return false
+	}
+}
+
+// lotPnL mirrors UpdateTrade's (exit-entry)*qty / (entry-exit)*qty
+// convention, but parameterized by entryPrice so CostModelAverageCost can
+// substitute the blended price instead of the lot's own.
+func lotPnL(tradeType models.TradeType, entryPrice, exitPrice, quantity float64) float64 {
+	if tradeType == models.TradeBuy {
+		return (exitPrice - entryPrice) * quantity
+	}
+	return (entryPrice - exitPrice) * quantity
+}
+
+// storedActivity mirrors snaptrade-service's StoredActivity row shape.
+// Go-backend and snaptrade-service are separate Go modules that don't
+// import each other, but they share the same Supabase project, so this
+// reconciler reads the "activities" table snaptrade-service's sync
+// pipeline already keeps up to date rather than calling that service
+// over HTTP.
+type storedActivity struct {
+	ActivityID string  `json:"activity_id"`
+	AccountID  string  `json:"account_id"`
+	UserID     int64   `json:"user_id"`
+	Type       string  `json:"type"`
+	Symbol     string  `json:"symbol"`
+	Quantity   float64 `json:"quantity"`
+	Price      float64 `json:"price"`
+	TradeDate  string  `json:"trade_date"`
+}
+
+// SupabaseActivitySource is the ActivitySource backed by the shared
+// "activities" table.
+type SupabaseActivitySource struct {
+	db *DatabaseService
+}
+
+// NewSupabaseActivitySource builds a SupabaseActivitySource.
+func NewSupabaseActivitySource(db *DatabaseService) *SupabaseActivitySource {
+	return &SupabaseActivitySource{db: db}
+}
+
+// FetchFills reads every buy/sell activity recorded for userID since the
+// given time and translates it into a BrokerFill.
+func (a *SupabaseActivitySource) FetchFills(ctx context.Context, userID int64, since time.Time) ([]BrokerFill, error) {
+	var rows []storedActivity
+	opts := SelectOptions{
+		Eq:  map[string]interface{}{"user_id": userID},
+		Gte: map[string]interface{}{"trade_date": since.Format("2006-01-02")},
+	}
+	if err := a.db.SelectWithOptions(ctx, activitiesTable, "*", opts, &rows); err != nil {
+		return nil, fmt.Errorf("failed to select activities: %w", err)
+	}
+
+	var fills []BrokerFill
+	for _, row := range rows {
+		side, ok := activityTypeToTradeType(row.Type)
+		if !ok {
+			continue
+		}
+		filledAt, err := time.Parse("2006-01-02", row.TradeDate)
+		if err != nil {
+			filledAt = since
+		}
+		fills = append(fills, BrokerFill{
+			ExternalID: row.ActivityID,
+			Symbol:     row.Symbol,
+			Side:       side,
+			Quantity:   math.Abs(row.Quantity),
+			Price:      row.Price,
+			FilledAt:   filledAt,
+		})
+	}
+	return fills, nil
+}
+
+// activityTypeToTradeType maps a snaptrade-service activity "type" (e.g.
+// "BUY"/"SELL") to the TradeType it represents. Non-trade activities
+// (dividends, transfers, fees, ...) are reported as not-ok so callers can
+// skip them.
+func activityTypeToTradeType(activityType string) (models.TradeType, bool) {
+	switch activityType {
+	case "BUY", "buy":
+		return models.TradeBuy, true
+	case "SELL", "sell":
+		return models.TradeSell, true
+	default:
+		return "", false
+	}
+}
+
+// activitiesTable is the Supabase table snaptrade-service's sync pipeline
+// upserts synced brokerage activities into.
+const activitiesTable = "activities"
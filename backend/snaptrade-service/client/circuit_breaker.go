@@ -0,0 +1,109 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker trips to open after threshold consecutive failures, then
+// allows a single half-open probe once cooldown has elapsed. A successful
+// probe closes the breaker again; a failed probe reopens it.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	threshold int
+	cooldown  time.Duration
+
+	state         breakerState
+	failures      int
+	openedAt      time.Time
+	probeInFlight bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     breakerClosed,
+	}
+}
+
+// Allow reports whether a request may proceed, transitioning an open
+// breaker to half-open once the cooldown window has passed.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.transition(breakerHalfOpen)
+		b.probeInFlight = true
+		return true
+	case breakerHalfOpen:
+		// Only the probe that flipped us into half-open may proceed;
+		// everything else fast-fails until it resolves.
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probeInFlight = false
+	if b.state != breakerClosed {
+		b.transition(breakerClosed)
+	}
+}
+
+func (b *circuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.probeInFlight = false
+		b.transition(breakerOpen)
+		b.openedAt = time.Now()
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.transition(breakerOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+// transition records the new state and emits the corresponding metric. Must
+// be called with b.mu held.
+func (b *circuitBreaker) transition(to breakerState) {
+	b.state = to
+	metrics.breakerTransitions.WithLabelValues(to.String()).Inc()
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+// Doer wraps an http.Client with a shared rate limiter, retry-with-backoff,
+// and a circuit breaker, so every outbound SnapTrade SDK call goes through
+// the same resilience policy regardless of which API method issued it.
+type Doer struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+	breaker *circuitBreaker
+
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// doerMetrics holds the Prometheus counters shared by every Doer instance.
+type doerMetrics struct {
+	attempts           prometheus.Counter
+	retries            prometheus.Counter
+	tooManyRequests    prometheus.Counter
+	breakerTransitions *prometheus.CounterVec
+}
+
+var (
+	metricsOnce sync.Once
+	metrics     doerMetrics
+)
+
+func initMetrics() {
+	metrics = doerMetrics{
+		attempts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "snaptrade_client_requests_total",
+			Help: "Total outbound requests attempted against the SnapTrade API.",
+		}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "snaptrade_client_retries_total",
+			Help: "Total retries performed against the SnapTrade API.",
+		}),
+		tooManyRequests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "snaptrade_client_429_total",
+			Help: "Total 429 Too Many Requests responses received from the SnapTrade API.",
+		}),
+		breakerTransitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "snaptrade_client_breaker_transitions_total",
+			Help: "Circuit breaker state transitions, labeled by the state entered.",
+		}, []string{"state"}),
+	}
+	prometheus.MustRegister(
+		metrics.attempts,
+		metrics.retries,
+		metrics.tooManyRequests,
+		metrics.breakerTransitions,
+	)
+}
+
+// NewDoer builds a Doer around next (the real transport), configured from
+// SNAPTRADE_RATE_LIMIT_RPS, SNAPTRADE_RATE_LIMIT_BURST, SNAPTRADE_MAX_ATTEMPTS,
+// SNAPTRADE_RETRY_BASE_MS, and SNAPTRADE_RETRY_MAX_MS.
+func NewDoer(next http.RoundTripper) *Doer {
+	metricsOnce.Do(initMetrics)
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	rps := floatFromEnv("SNAPTRADE_RATE_LIMIT_RPS", 5)
+	burst := intFromEnv("SNAPTRADE_RATE_LIMIT_BURST", 10)
+
+	return &Doer{
+		next:        next,
+		limiter:     rate.NewLimiter(rate.Limit(rps), burst),
+		breaker:     newCircuitBreaker(intFromEnv("SNAPTRADE_BREAKER_THRESHOLD", 5), durationFromEnvMs("SNAPTRADE_BREAKER_COOLDOWN_MS", 30*time.Second)),
+		maxAttempts: intFromEnv("SNAPTRADE_MAX_ATTEMPTS", 4),
+		baseDelay:   durationFromEnvMs("SNAPTRADE_RETRY_BASE_MS", 250*time.Millisecond),
+		maxDelay:    durationFromEnvMs("SNAPTRADE_RETRY_MAX_MS", 8*time.Second),
+	}
+}
+
+// RoundTrip implements http.RoundTripper: it blocks on the limiter, retries
+// on 429/5xx with exponential backoff and jitter honoring Retry-After, and
+// fast-fails while the circuit breaker is open.
+func (d *Doer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !d.breaker.Allow() {
+		return nil, fmt.Errorf("snaptrade client: circuit breaker open, fast-failing request to %s", req.URL.Path)
+	}
+
+	var lastResp *http.Response
+	var lastErr error
+
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		if err := d.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("snaptrade client: rate limiter wait: %w", err)
+		}
+
+		metrics.attempts.Inc()
+		resp, err := d.next.RoundTrip(req)
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			d.breaker.RecordSuccess()
+			return resp, nil
+		}
+
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			metrics.tooManyRequests.Inc()
+		}
+
+		lastResp, lastErr = resp, err
+		d.breaker.RecordFailure()
+
+		if attempt == d.maxAttempts {
+			break
+		}
+
+		delay := d.retryDelay(attempt, resp)
+		metrics.retries.Inc()
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return lastResp, nil
+}
+
+// retryDelay computes the next backoff, honoring a Retry-After header when
+// present and applying ±20% jitter on top of the exponential base.
+func (d *Doer) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			if seconds, err := strconv.Atoi(retryAfter); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	delay := d.baseDelay << uint(attempt-1)
+	if delay > d.maxDelay {
+		delay = d.maxDelay
+	}
+
+	jitter := float64(delay) * 0.2
+	offset := (rand.Float64()*2 - 1) * jitter
+	return delay + time.Duration(offset)
+}
+
+func floatFromEnv(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"time"
 
 	snaptrade "github.com/passiv/snaptrade-sdks/sdks/go"
 )
 
 type SnapTradeClient struct {
-	client   *snaptrade.APIClient
-	ctx      context.Context
+	config   *snaptrade.Configuration
 	clientId string
+	timeouts TimeoutConfig
 }
 
 func NewSnapTradeClient() (*SnapTradeClient, error) {
@@ -45,21 +47,49 @@ func NewSnapTradeClient() (*SnapTradeClient, error) {
 	config.AddDefaultHeader("clientId", clientId)
 	config.AddDefaultHeader("consumerKey", consumerKey)
 
-	apiClient := snaptrade.NewAPIClient(config)
+	// Route every outbound call through the shared rate limiter, retry,
+	// and circuit breaker policy.
+	config.HTTPClient = &http.Client{
+		Transport: NewDoer(http.DefaultTransport),
+	}
 
 	return &SnapTradeClient{
-		client:   apiClient,
-		ctx:      context.Background(),
+		config:   config,
 		clientId: clientId,
+		timeouts: DefaultTimeoutConfig(),
 	}, nil
 }
 
+// withTimeout derives a context bounded by the category timeout unless the
+// caller's context already carries an earlier deadline.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// apiClientFor returns an SDK client bound to ctx. The SDK resolves the
+// request context from its Configuration at construction time rather than
+// per-call, so each outbound call gets a cheap, request-scoped client built
+// from a shallow copy of the shared configuration (same HTTP client, same
+// credentials, distinct context).
+func (c *SnapTradeClient) apiClientFor(ctx context.Context) *snaptrade.APIClient {
+	cfg := *c.config
+	cfg.Context = ctx
+	return snaptrade.NewAPIClient(&cfg)
+}
+
 // CreateUser creates a new SnapTrade user
-func (c *SnapTradeClient) CreateUser(userId string) (*snaptrade.UserIDandSecret, error) {
+func (c *SnapTradeClient) CreateUser(ctx context.Context, userId string) (*snaptrade.UserIDandSecret, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
 	registerBody := snaptrade.NewSnapTradeRegisterUserRequestBody(userId)
 
-	req := c.client.AuthenticationApi.RegisterSnapTradeUser(*registerBody)
-	result, httpResp, err := c.client.AuthenticationApi.RegisterSnapTradeUserExecute(req)
+	req := client.AuthenticationApi.RegisterSnapTradeUser(*registerBody)
+	result, httpResp, err := client.AuthenticationApi.RegisterSnapTradeUserExecute(req)
 	if err != nil {
 		// Log detailed error information
 		if httpResp != nil {
@@ -68,12 +98,12 @@ func (c *SnapTradeClient) CreateUser(userId string) (*snaptrade.UserIDandSecret,
 				// Try to read response body for better error message
 				bodyBytes, _ = io.ReadAll(httpResp.Body)
 			}
-			fmt.Printf("SnapTrade API Error - Status: %d, Body: %s, Error: %v\n", 
+			fmt.Printf("SnapTrade API Error - Status: %d, Body: %s, Error: %v\n",
 				httpResp.StatusCode, string(bodyBytes), err)
-			
+
 			// Check if user already exists (400 Bad Request often means user exists)
 			if httpResp.StatusCode == 400 {
-				return nil, fmt.Errorf("user may already exist or invalid request (400 Bad Request): %s - %w", 
+				return nil, fmt.Errorf("user may already exist or invalid request (400 Bad Request): %s - %w",
 					string(bodyBytes), err)
 			}
 		}
@@ -88,15 +118,19 @@ func (c *SnapTradeClient) CreateUser(userId string) (*snaptrade.UserIDandSecret,
 // Returns: { "status": "deleted", "detail": "...", "userId": "..." }
 // Note: User deletion is queued and happens asynchronously
 // The SDK method only takes userId, userSecret must be passed via context or request
-func (c *SnapTradeClient) DeleteUser(userId, userSecret string) error {
+func (c *SnapTradeClient) DeleteUser(ctx context.Context, userId, userSecret string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
 	// SDK method only takes userId
-	req := c.client.AuthenticationApi.DeleteSnapTradeUser(userId)
-	
+	req := client.AuthenticationApi.DeleteSnapTradeUser(userId)
+
 	// Note: The SDK may handle userSecret via context or the request may need it
 	// If the API requires userSecret, it might be in the request body or headers
 	// For now, we'll try with just userId and see if it works
-	
-	result, httpResp, err := c.client.AuthenticationApi.DeleteSnapTradeUserExecute(req)
+
+	result, httpResp, err := client.AuthenticationApi.DeleteSnapTradeUserExecute(req)
 	if err != nil {
 		if httpResp != nil {
 			bodyBytes := make([]byte, 0)
@@ -107,7 +141,7 @@ func (c *SnapTradeClient) DeleteUser(userId, userSecret string) error {
 			if httpResp.StatusCode == 401 || httpResp.StatusCode == 403 {
 				return fmt.Errorf("user_secret required to delete user (401/403): %s", string(bodyBytes))
 			}
-			fmt.Printf("SnapTrade Delete User API Error - Status: %d, Body: %s, Error: %v\n", 
+			fmt.Printf("SnapTrade Delete User API Error - Status: %d, Body: %s, Error: %v\n",
 				httpResp.StatusCode, string(bodyBytes), err)
 		}
 		return fmt.Errorf("failed to delete SnapTrade user: %w", err)
@@ -122,14 +156,18 @@ func (c *SnapTradeClient) DeleteUser(userId, userSecret string) error {
 }
 
 // GenerateConnectionPortalURL generates a connection portal URL for the user
-func (c *SnapTradeClient) GenerateConnectionPortalURL(userId, userSecret, brokerageId string, connectionType string) (*snaptrade.LoginRedirectURI, error) {
-	req := c.client.AuthenticationApi.LoginSnapTradeUser(userId, userSecret)
+func (c *SnapTradeClient) GenerateConnectionPortalURL(ctx context.Context, userId, userSecret, brokerageId string, connectionType string) (*snaptrade.LoginRedirectURI, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.AuthenticationApi.LoginSnapTradeUser(userId, userSecret)
 
 	// Note: The SDK doesn't seem to have a direct way to specify brokerage or connection type
 	// in LoginSnapTradeUser. The connection portal URL is generic and the user selects
 	// the brokerage in the portal itself.
 
-	response, httpResp, err := c.client.AuthenticationApi.LoginSnapTradeUserExecute(req)
+	response, httpResp, err := client.AuthenticationApi.LoginSnapTradeUserExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate connection portal URL: %w", err)
 	}
@@ -156,9 +194,13 @@ func (c *SnapTradeClient) GenerateConnectionPortalURL(userId, userSecret, broker
 // GetConnectionStatus checks the status of a connection
 // Note: SnapTrade SDK doesn't have a direct "GetConnectionStatus" method
 // We'll use ListBrokerageAuthorizations to check if a connection exists
-func (c *SnapTradeClient) GetConnectionStatus(userId, userSecret, connectionId string) (*snaptrade.BrokerageAuthorization, error) {
-	req := c.client.ConnectionsApi.ListBrokerageAuthorizations(userId, userSecret)
-	authorizations, _, err := c.client.ConnectionsApi.ListBrokerageAuthorizationsExecute(req)
+func (c *SnapTradeClient) GetConnectionStatus(ctx context.Context, userId, userSecret, connectionId string) (*snaptrade.BrokerageAuthorization, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.ConnectionsApi.ListBrokerageAuthorizations(userId, userSecret)
+	authorizations, _, err := client.ConnectionsApi.ListBrokerageAuthorizationsExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list connections: %w", err)
 	}
@@ -174,9 +216,13 @@ func (c *SnapTradeClient) GetConnectionStatus(userId, userSecret, connectionId s
 }
 
 // ListConnections lists all connections for a user
-func (c *SnapTradeClient) ListConnections(userId, userSecret string) ([]snaptrade.BrokerageAuthorization, error) {
-	req := c.client.ConnectionsApi.ListBrokerageAuthorizations(userId, userSecret)
-	authorizations, _, err := c.client.ConnectionsApi.ListBrokerageAuthorizationsExecute(req)
+func (c *SnapTradeClient) ListConnections(ctx context.Context, userId, userSecret string) ([]snaptrade.BrokerageAuthorization, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.ConnectionsApi.ListBrokerageAuthorizations(userId, userSecret)
+	authorizations, _, err := client.ConnectionsApi.ListBrokerageAuthorizationsExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list connections: %w", err)
 	}
@@ -185,9 +231,13 @@ func (c *SnapTradeClient) ListConnections(userId, userSecret string) ([]snaptrad
 }
 
 // DeleteConnection deletes a connection
-func (c *SnapTradeClient) DeleteConnection(userId, userSecret, connectionId string) error {
-	req := c.client.ConnectionsApi.RemoveBrokerageAuthorization(connectionId, userId, userSecret)
-	_, err := c.client.ConnectionsApi.RemoveBrokerageAuthorizationExecute(req)
+func (c *SnapTradeClient) DeleteConnection(ctx context.Context, userId, userSecret, connectionId string) error {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Auth)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.ConnectionsApi.RemoveBrokerageAuthorization(connectionId, userId, userSecret)
+	_, err := client.ConnectionsApi.RemoveBrokerageAuthorizationExecute(req)
 	if err != nil {
 		return fmt.Errorf("failed to delete connection: %w", err)
 	}
@@ -196,9 +246,13 @@ func (c *SnapTradeClient) DeleteConnection(userId, userSecret, connectionId stri
 }
 
 // ListAccounts lists all accounts for a user
-func (c *SnapTradeClient) ListAccounts(userId, userSecret string) ([]snaptrade.Account, error) {
-	req := c.client.AccountInformationApi.ListUserAccounts(userId, userSecret)
-	accounts, _, err := c.client.AccountInformationApi.ListUserAccountsExecute(req)
+func (c *SnapTradeClient) ListAccounts(ctx context.Context, userId, userSecret string) ([]snaptrade.Account, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.AccountInformationApi.ListUserAccounts(userId, userSecret)
+	accounts, _, err := client.AccountInformationApi.ListUserAccountsExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list accounts: %w", err)
 	}
@@ -207,9 +261,13 @@ func (c *SnapTradeClient) ListAccounts(userId, userSecret string) ([]snaptrade.A
 }
 
 // GetAccountDetail gets detailed information about a specific account
-func (c *SnapTradeClient) GetAccountDetail(userId, userSecret, accountId string) (*snaptrade.Account, error) {
-	req := c.client.AccountInformationApi.GetUserAccountDetails(userId, userSecret, accountId)
-	account, _, err := c.client.AccountInformationApi.GetUserAccountDetailsExecute(req)
+func (c *SnapTradeClient) GetAccountDetail(ctx context.Context, userId, userSecret, accountId string) (*snaptrade.Account, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.AccountInformationApi.GetUserAccountDetails(userId, userSecret, accountId)
+	account, _, err := client.AccountInformationApi.GetUserAccountDetailsExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account detail: %w", err)
 	}
@@ -218,9 +276,13 @@ func (c *SnapTradeClient) GetAccountDetail(userId, userSecret, accountId string)
 }
 
 // GetHoldings gets current holdings for an account
-func (c *SnapTradeClient) GetHoldings(userId, userSecret, accountId string) (*snaptrade.AccountHoldingsAccount, error) {
-	req := c.client.AccountInformationApi.GetUserHoldings(accountId, userId, userSecret)
-	holdings, _, err := c.client.AccountInformationApi.GetUserHoldingsExecute(req)
+func (c *SnapTradeClient) GetHoldings(ctx context.Context, userId, userSecret, accountId string) (*snaptrade.AccountHoldingsAccount, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Read)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.AccountInformationApi.GetUserHoldings(accountId, userId, userSecret)
+	holdings, _, err := client.AccountInformationApi.GetUserHoldingsExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get holdings: %w", err)
 	}
@@ -230,37 +292,27 @@ func (c *SnapTradeClient) GetHoldings(userId, userSecret, accountId string) (*sn
 
 // GetTransactions gets transactions for an account
 // Note: SnapTrade SDK uses GetActivities which returns UniversalActivity
-func (c *SnapTradeClient) GetTransactions(userId, userSecret, accountId string, startDate, endDate *string) ([]snaptrade.UniversalActivity, error) {
-	req := c.client.TransactionsAndReportingApi.GetActivities(userId, userSecret)
+func (c *SnapTradeClient) GetTransactions(ctx context.Context, userId, userSecret, accountId string, startDate, endDate *string) ([]snaptrade.UniversalActivity, error) {
+	ctx, cancel := withTimeout(ctx, c.timeouts.Transactions)
+	defer cancel()
+	client := c.apiClientFor(ctx)
+
+	req := client.TransactionsAndReportingApi.GetActivities(userId, userSecret)
 
-	// Add account filter if provided
 	if accountId != "" {
-		// Note: The SDK may not support account filtering directly in GetActivities
-		// You may need to filter the results after fetching
+		req.Accounts(accountId)
 	}
-
-	// Add date range if provided
 	if startDate != nil {
-		// Note: Date filtering may need to be done via GetReportingCustomRange
-		// or filtered after fetching
+		req.StartDate(*startDate)
+	}
+	if endDate != nil {
+		req.EndDate(*endDate)
 	}
 
-	activities, _, err := c.client.TransactionsAndReportingApi.GetActivitiesExecute(req)
+	activities, _, err := client.TransactionsAndReportingApi.GetActivitiesExecute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
-	// Filter by account if accountId is provided
-	if accountId != "" {
-		filtered := make([]snaptrade.UniversalActivity, 0)
-		for _, activity := range activities {
-			// Check if activity belongs to the account
-			// This depends on the UniversalActivity structure
-			// For now, return all activities
-			filtered = append(filtered, activity)
-		}
-		return filtered, nil
-	}
-
 	return activities, nil
 }
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// TimeoutConfig holds the default per-category timeouts applied to outbound
+// SnapTrade calls when the caller's context has no deadline of its own.
+type TimeoutConfig struct {
+	Auth         time.Duration
+	Read         time.Duration
+	Transactions time.Duration
+}
+
+// DefaultTimeoutConfig returns the built-in timeouts, overridable via
+// SNAPTRADE_TIMEOUT_AUTH_MS, SNAPTRADE_TIMEOUT_READ_MS, and
+// SNAPTRADE_TIMEOUT_TRANSACTIONS_MS (all in milliseconds).
+func DefaultTimeoutConfig() TimeoutConfig {
+	return TimeoutConfig{
+		Auth:         durationFromEnvMs("SNAPTRADE_TIMEOUT_AUTH_MS", 10*time.Second),
+		Read:         durationFromEnvMs("SNAPTRADE_TIMEOUT_READ_MS", 15*time.Second),
+		Transactions: durationFromEnvMs("SNAPTRADE_TIMEOUT_TRANSACTIONS_MS", 30*time.Second),
+	}
+}
+
+func durationFromEnvMs(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms <= 0 {
+		return defaultValue
+	}
+	return time.Duration(ms) * time.Millisecond
+}
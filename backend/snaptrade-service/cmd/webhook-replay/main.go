@@ -0,0 +1,87 @@
+// Command webhook-replay re-invokes the SnapTrade webhook dispatcher for
+// events previously stored in Supabase, e.g. after fixing a handler bug or
+// recovering from a dispatcher outage.
+//
+// Usage:
+//
+//	go run ./cmd/webhook-replay [-since 2006-01-02T15:04:05Z] [-unprocessed-only]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"snaptrade-service/client"
+	snaptradeprovider "snaptrade-service/provider/snaptrade"
+	"snaptrade-service/services"
+	"snaptrade-service/webhook"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	since := flag.String("since", "", "only replay events received at or after this RFC3339 timestamp")
+	unprocessedOnly := flag.Bool("unprocessed-only", false, "only replay events that were never marked processed")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Warning: Error loading .env file: %v. Will try to use environment variables directly.", err)
+	}
+
+	db, err := services.NewDatabaseService()
+	if err != nil {
+		log.Fatalf("Failed to initialize database service: %v", err)
+	}
+
+	snapTradeClient, err := client.NewSnapTradeClient()
+	if err != nil {
+		log.Fatalf("Failed to initialize SnapTrade client: %v", err)
+	}
+
+	sync := services.NewSyncService(db)
+	dispatcher := webhook.NewDispatcher(db, sync, snaptradeprovider.New(snapTradeClient))
+
+	ctx := context.Background()
+
+	var events []webhook.StoredEvent
+	if err := db.Select(ctx, "snaptrade_webhook_events", "*", map[string]interface{}{}, &events); err != nil {
+		log.Fatalf("Failed to load stored webhook events: %v", err)
+	}
+
+	var sinceTime time.Time
+	if *since != "" {
+		sinceTime, err = time.Parse(time.RFC3339, *since)
+		if err != nil {
+			log.Fatalf("Invalid -since timestamp: %v", err)
+		}
+	}
+
+	replayed := 0
+	for _, stored := range events {
+		if !sinceTime.IsZero() && stored.ReceivedAt.Before(sinceTime) {
+			continue
+		}
+		if *unprocessedOnly && !stored.ProcessedAt.IsZero() {
+			continue
+		}
+
+		var event webhook.Event
+		if err := json.Unmarshal([]byte(stored.Payload), &event); err != nil {
+			log.Printf("Skipping event %s: failed to decode stored payload: %v", stored.ID, err)
+			continue
+		}
+
+		if err := dispatcher.Replay(ctx, event, stored.Payload); err != nil {
+			log.Printf("Failed to replay event %s (%s): %v", event.ID, event.Type, err)
+			continue
+		}
+
+		replayed++
+	}
+
+	fmt.Printf("Replayed %d/%d stored webhook events\n", replayed, len(events))
+}
@@ -10,7 +10,6 @@ import (
 type InitiateConnectionRequest struct {
 	BrokerageID    string `json:"brokerage_id"`
 	ConnectionType string `json:"connection_type,omitempty"` // "read" or "trade", defaults to "read"
-	UserSecret     string `json:"user_secret"`               // Passed from Rust backend
 }
 
 // InitiateConnectionResponse represents the response from initiating a connection
@@ -22,12 +21,8 @@ type InitiateConnectionResponse struct {
 // InitiateConnection generates a connection portal URL
 func InitiateConnection(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
 		var req InitiateConnectionRequest
 		if err := c.BodyParser(&req); err != nil {
@@ -42,20 +37,15 @@ func InitiateConnection(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			})
 		}
 
-		if req.UserSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required",
-			})
-		}
-
 		connectionType := req.ConnectionType
 		if connectionType == "" {
 			connectionType = "read"
 		}
 
 		redirectToken, err := snapTradeClient.GenerateConnectionPortalURL(
+			c.UserContext(),
 			userId,
-			req.UserSecret,
+			userSecret,
 			req.BrokerageID,
 			connectionType,
 		)
@@ -90,12 +80,8 @@ func InitiateConnection(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 // GetConnectionStatus checks the status of a connection
 func GetConnectionStatus(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
 		connectionId := c.Params("connectionId")
 		if connectionId == "" {
@@ -104,18 +90,7 @@ func GetConnectionStatus(snapTradeClient *client.SnapTradeClient) fiber.Handler
 			})
 		}
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
-		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
-			})
-		}
-
-		status, err := snapTradeClient.GetConnectionStatus(userId, userSecret, connectionId)
+		status, err := snapTradeClient.GetConnectionStatus(c.UserContext(), userId, userSecret, connectionId)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": err.Error(),
@@ -129,25 +104,10 @@ func GetConnectionStatus(snapTradeClient *client.SnapTradeClient) fiber.Handler
 // ListConnections lists all connections for a user
 func ListConnections(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
-		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
-			})
-		}
-
-		connections, err := snapTradeClient.ListConnections(userId, userSecret)
+		connections, err := snapTradeClient.ListConnections(c.UserContext(), userId, userSecret)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": err.Error(),
@@ -161,12 +121,8 @@ func ListConnections(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 // DeleteConnection deletes a connection
 func DeleteConnection(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
 		connectionId := c.Params("connectionId")
 		if connectionId == "" {
@@ -175,18 +131,7 @@ func DeleteConnection(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			})
 		}
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
-		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
-			})
-		}
-
-		err := snapTradeClient.DeleteConnection(userId, userSecret, connectionId)
+		err := snapTradeClient.DeleteConnection(c.UserContext(), userId, userSecret, connectionId)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": err.Error(),
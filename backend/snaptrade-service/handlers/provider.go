@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+
+	"snaptrade-service/provider"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resolveProvider looks up the aggregator for this request: the ":provider"
+// path param takes precedence, then the X-Provider header, then the
+// registry's default provider.
+func resolveProvider(registry *provider.Registry, c *fiber.Ctx) (provider.Aggregator, error) {
+	name := c.Params("provider")
+	if name == "" {
+		name = c.Get("X-Provider")
+	}
+	return registry.Resolve(name)
+}
+
+func providerError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, provider.ErrNotImplemented) {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// ProviderListAccounts lists accounts through the resolved provider.
+func ProviderListAccounts(registry *provider.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		agg, err := resolveProvider(registry, c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		accounts, err := agg.ListAccounts(c.UserContext(), userId, userSecret)
+		if err != nil {
+			return providerError(c, err)
+		}
+		return c.JSON(accounts)
+	}
+}
+
+// ProviderGetHoldings fetches holdings for an account through the resolved provider.
+func ProviderGetHoldings(registry *provider.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		agg, err := resolveProvider(registry, c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		accountId := c.Params("accountId")
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		holdings, err := agg.GetHoldings(c.UserContext(), userId, userSecret, accountId)
+		if err != nil {
+			return providerError(c, err)
+		}
+		return c.JSON(holdings)
+	}
+}
+
+// ProviderGetTransactions fetches transactions for an account through the resolved provider.
+func ProviderGetTransactions(registry *provider.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		agg, err := resolveProvider(registry, c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		accountId := c.Params("accountId")
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		startDate := c.Query("start_date")
+		endDate := c.Query("end_date")
+		var startDatePtr, endDatePtr *string
+		if startDate != "" {
+			startDatePtr = &startDate
+		}
+		if endDate != "" {
+			endDatePtr = &endDate
+		}
+
+		activities, err := agg.GetTransactions(c.UserContext(), userId, userSecret, accountId, startDatePtr, endDatePtr)
+		if err != nil {
+			return providerError(c, err)
+		}
+		return c.JSON(activities)
+	}
+}
+
+// ProviderListConnections lists connections through the resolved provider.
+func ProviderListConnections(registry *provider.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		agg, err := resolveProvider(registry, c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		connections, err := agg.ListConnections(c.UserContext(), userId, userSecret)
+		if err != nil {
+			return providerError(c, err)
+		}
+		return c.JSON(connections)
+	}
+}
+
+// ProviderDeleteConnection deletes a connection through the resolved provider.
+func ProviderDeleteConnection(registry *provider.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		agg, err := resolveProvider(registry, c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		connectionId := c.Params("connectionId")
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		if err := agg.DeleteConnection(c.UserContext(), userId, userSecret, connectionId); err != nil {
+			return providerError(c, err)
+		}
+		return c.JSON(fiber.Map{"success": true})
+	}
+}
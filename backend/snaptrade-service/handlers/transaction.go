@@ -1,8 +1,17 @@
 package handlers
 
 import (
-	"snaptrade-service/client"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
 	"strconv"
+	"time"
+
+	"snaptrade-service/client"
+	"snaptrade-service/options"
+	"snaptrade-service/services"
+
+	snaptrade "github.com/passiv/snaptrade-sdks/sdks/go"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -26,15 +35,16 @@ type GetTransactionsResponse struct {
 	} `json:"pagination"`
 }
 
-// GetTransactions fetches transactions for an account with pagination support
-func GetTransactions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
+// GetTransactions serves cached transactions for an account with
+// cursor-based pagination (?cursor=...&limit=...). Pass ?fresh=true to
+// bypass the cache and fetch directly from the aggregator, paginated by an
+// opaque ?page_token= cursor (see transactionCursor). ?legacy=true preserves
+// the old offset/limit full-fetch-and-slice behavior on the fresh path for
+// one release.
+func GetTransactions(snapTradeClient *client.SnapTradeClient, sync *services.SyncService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
 		accountId := c.Params("accountId")
 		if accountId == "" {
@@ -43,85 +53,183 @@ func GetTransactions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			})
 		}
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
+		if c.Query("fresh") != "true" {
+			return getCachedTransactions(c, sync, accountId)
 		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
-			})
+
+		if c.Query("legacy") == "true" {
+			return legacyFreshTransactions(c, snapTradeClient, userId, userSecret, accountId)
 		}
 
-		// Parse query parameters
-		startDate := c.Query("start_date")
-		endDate := c.Query("end_date")
-		offsetStr := c.Query("offset")
-		limitStr := c.Query("limit")
+		return freshTransactionsPage(c, snapTradeClient, userId, userSecret, accountId)
+	}
+}
 
-		var startDatePtr *string
-		var endDatePtr *string
-		if startDate != "" {
-			startDatePtr = &startDate
-		}
-		if endDate != "" {
-			endDatePtr = &endDate
-		}
+// transactionCursor identifies the last row of the previous page, so the
+// next page can both narrow the aggregator's date range (startDatePtr) and
+// exclude rows already returned, the same way Alpaca's next_page_token
+// works.
+type transactionCursor struct {
+	LastExecutedAt time.Time `json:"last_executed_at"`
+	LastID         string    `json:"last_id"`
+}
 
-		// Default pagination values
-		offset := 0
-		limit := 1000 // SnapTrade default
+func encodeTransactionCursor(cursor transactionCursor) (string, error) {
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(raw), nil
+}
 
-		if offsetStr != "" {
-			if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-				offset = parsedOffset
-			}
+func decodeTransactionCursor(token string) (*transactionCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var cursor transactionCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// afterCursor reports whether (executedAt, id) sorts strictly after cursor
+// in the (executed_at desc, id desc) ordering this endpoint returns - i.e.
+// whether it belongs on a later page than the one cursor ended.
+func afterCursor(executedAt time.Time, id string, cursor *transactionCursor) bool {
+	if cursor == nil {
+		return true
+	}
+	if executedAt.Before(cursor.LastExecutedAt) {
+		return true
+	}
+	return executedAt.Equal(cursor.LastExecutedAt) && id < cursor.LastID
+}
+
+const defaultTransactionsPageLimit = 100
+
+// freshTransactionsPage serves one page of live, uncached transactions,
+// narrowing the aggregator request to the cursor's date instead of fetching
+// the account's entire history and slicing it in memory.
+func freshTransactionsPage(c *fiber.Ctx, snapTradeClient *client.SnapTradeClient, userId, userSecret, accountId string) error {
+	limit := defaultTransactionsPageLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
 		}
+	}
 
-		if limitStr != "" {
-			if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-				limit = parsedLimit
-			}
+	cursor, err := decodeTransactionCursor(c.Query("page_token"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid page_token",
+		})
+	}
+
+	var startDatePtr *string
+	if cursor != nil {
+		startDate := cursor.LastExecutedAt.Format("2006-01-02")
+		startDatePtr = &startDate
+	} else if startDate := c.Query("start_date"); startDate != "" {
+		startDatePtr = &startDate
+	}
+
+	var endDatePtr *string
+	if endDate := c.Query("end_date"); endDate != "" {
+		endDatePtr = &endDate
+	}
+
+	transactions, err := snapTradeClient.GetTransactions(c.UserContext(), userId, userSecret, accountId, startDatePtr, endDatePtr)
+	if err != nil {
+		return c.JSON(fiber.Map{
+			"data": []interface{}{},
+		})
+	}
+
+	remaining := make([]snaptrade.UniversalActivity, 0, len(transactions))
+	for _, activity := range transactions {
+		if afterCursor(activity.GetTradeDate(), activity.GetId(), cursor) {
+			remaining = append(remaining, activity)
 		}
+	}
 
-		transactions, err := snapTradeClient.GetTransactions(userId, userSecret, accountId, startDatePtr, endDatePtr)
-		if err != nil {
-			// Handle empty transactions gracefully - return empty array
-			return c.JSON(GetTransactionsResponse{
-				Data: []interface{}{},
-				Pagination: struct {
-					Offset int `json:"offset"`
-					Limit  int `json:"limit"`
-					Total  int `json:"total"`
-				}{
-					Offset: offset,
-					Limit:  limit,
-					Total:  0,
-				},
-			})
+	sort.Slice(remaining, func(i, j int) bool {
+		ti, tj := remaining[i].GetTradeDate(), remaining[j].GetTradeDate()
+		if !ti.Equal(tj) {
+			return ti.After(tj)
 		}
+		return remaining[i].GetId() > remaining[j].GetId()
+	})
 
-		// Apply pagination
-		total := len(transactions)
-		start := offset
-		end := offset + limit
-		if start > total {
-			start = total
+	hasMore := len(remaining) > limit
+	if hasMore {
+		remaining = remaining[:limit]
+	}
+
+	data := make([]interface{}, len(remaining))
+	for i, activity := range remaining {
+		data[i] = activity
+	}
+
+	response := fiber.Map{"data": data}
+	if hasMore && len(remaining) > 0 {
+		last := remaining[len(remaining)-1]
+		nextToken, err := encodeTransactionCursor(transactionCursor{
+			LastExecutedAt: last.GetTradeDate(),
+			LastID:         last.GetId(),
+		})
+		if err == nil {
+			response["next_page_token"] = nextToken
 		}
-		if end > total {
-			end = total
+	}
+
+	return c.JSON(response)
+}
+
+// legacyFreshTransactions preserves the original ?fresh=true behavior: fetch
+// the account's full activity history and slice it by offset/limit in
+// memory. O(N) per page - kept for one release behind ?legacy=true while
+// callers migrate to ?page_token=.
+func legacyFreshTransactions(c *fiber.Ctx, snapTradeClient *client.SnapTradeClient, userId, userSecret, accountId string) error {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	offsetStr := c.Query("offset")
+	limitStr := c.Query("limit")
+
+	var startDatePtr *string
+	var endDatePtr *string
+	if startDate != "" {
+		startDatePtr = &startDate
+	}
+	if endDate != "" {
+		endDatePtr = &endDate
+	}
+
+	// Default pagination values
+	offset := 0
+	limit := 1000 // SnapTrade default
+
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
 		}
+	}
 
-		var paginatedData []interface{}
-		if start < end {
-			for i := start; i < end; i++ {
-				paginatedData = append(paginatedData, transactions[i])
-			}
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
+			limit = parsedLimit
 		}
+	}
 
-		response := GetTransactionsResponse{
-			Data: paginatedData,
+	transactions, err := snapTradeClient.GetTransactions(c.UserContext(), userId, userSecret, accountId, startDatePtr, endDatePtr)
+	if err != nil {
+		// Handle empty transactions gracefully - return empty array
+		return c.JSON(GetTransactionsResponse{
+			Data: []interface{}{},
 			Pagination: struct {
 				Offset int `json:"offset"`
 				Limit  int `json:"limit"`
@@ -129,24 +237,73 @@ func GetTransactions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			}{
 				Offset: offset,
 				Limit:  limit,
-				Total:  total,
+				Total:  0,
 			},
+		})
+	}
+
+	// Apply pagination
+	total := len(transactions)
+	start := offset
+	end := offset + limit
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	var paginatedData []interface{}
+	if start < end {
+		for i := start; i < end; i++ {
+			paginatedData = append(paginatedData, transactions[i])
 		}
+	}
 
-		return c.JSON(response)
+	response := GetTransactionsResponse{
+		Data: paginatedData,
+		Pagination: struct {
+			Offset int `json:"offset"`
+			Limit  int `json:"limit"`
+			Total  int `json:"total"`
+		}{
+			Offset: offset,
+			Limit:  limit,
+			Total:  total,
+		},
 	}
+
+	return c.JSON(response)
 }
 
-// GetHoldings fetches current equity positions for an account
-func GetHoldings(snapTradeClient *client.SnapTradeClient) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
+// getCachedTransactions serves activities from the Supabase cache, paginated
+// by activity_id cursor instead of the legacy offset/limit scheme.
+func getCachedTransactions(c *fiber.Ctx, sync *services.SyncService, accountId string) error {
+	cursor := c.Query("cursor")
+	limit := 100
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
 		}
+	}
+
+	activities, nextCursor, err := sync.ListActivities(c.UserContext(), accountId, cursor, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":        activities,
+		"next_cursor": nextCursor,
+	})
+}
 
+// GetHoldings serves cached equity positions for an account. Pass
+// ?fresh=true to bypass the cache and fetch directly from the aggregator.
+func GetHoldings(snapTradeClient *client.SnapTradeClient, sync *services.SyncService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 		accountId := c.Params("accountId")
 		if accountId == "" {
 			return c.Status(400).JSON(fiber.Map{
@@ -154,18 +311,22 @@ func GetHoldings(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			})
 		}
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
-		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
+		if c.Query("fresh") != "true" {
+			holdings, err := sync.CachedHoldings(c.UserContext(), accountId)
+			if err != nil {
+				return c.Status(500).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			return c.JSON(fiber.Map{
+				"positions": holdings,
 			})
 		}
 
-		holdings, err := snapTradeClient.GetHoldings(userId, userSecret, accountId)
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
+
+		holdings, err := snapTradeClient.GetHoldings(c.UserContext(), userId, userSecret, accountId)
 		if err != nil {
 			// Handle empty holdings gracefully
 			return c.Status(500).JSON(fiber.Map{
@@ -183,16 +344,8 @@ func GetHoldings(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 		positions := holdings.GetPositions()
 		equityPositions := make([]interface{}, 0)
 
-		// TODO: Properly filter equity vs options based on SnapTrade SDK structure
-		// For now, return all positions as equity (options will be filtered in GetOptionPositions)
 		for _, position := range positions {
-			// Check if this is clearly an option by examining the position structure
-			// This is a simplified approach - may need refinement based on actual data
-			isOption := false
-
-			// Try to detect options - this is a placeholder that needs actual SDK inspection
-			// Options typically have different structures or type indicators
-			if !isOption {
+			if !options.Classify(position) {
 				equityPositions = append(equityPositions, position)
 			}
 		}
@@ -206,12 +359,8 @@ func GetHoldings(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 // GetOptionPositions fetches current option positions for an account
 func GetOptionPositions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userId := c.Get("X-User-Id")
-		if userId == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "Missing user ID",
-			})
-		}
+		userId := c.Locals("userId").(string)
+		userSecret := c.Locals("userSecret").(string)
 
 		accountId := c.Params("accountId")
 		if accountId == "" {
@@ -220,18 +369,7 @@ func GetOptionPositions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 			})
 		}
 
-		// Get user_secret from header or query parameter
-		userSecret := c.Get("X-User-Secret")
-		if userSecret == "" {
-			userSecret = c.Query("user_secret")
-		}
-		if userSecret == "" {
-			return c.Status(400).JSON(fiber.Map{
-				"error": "user_secret is required (header X-User-Secret or query param)",
-			})
-		}
-
-		holdings, err := snapTradeClient.GetHoldings(userId, userSecret, accountId)
+		holdings, err := snapTradeClient.GetHoldings(c.UserContext(), userId, userSecret, accountId)
 		if err != nil {
 			// Handle empty holdings gracefully - return empty array
 			return c.JSON(fiber.Map{
@@ -249,17 +387,8 @@ func GetOptionPositions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 		positions := holdings.GetPositions()
 		optionPositions := make([]interface{}, 0)
 
-		// TODO: Properly filter options based on SnapTrade SDK structure
-		// For now, return empty array - this needs to be implemented based on actual position structure
-		// Options may have a type field, symbol format, or other indicators
 		for _, position := range positions {
-			// Check if this is an option position
-			// This is a placeholder - needs actual implementation based on SDK structure
-			isOption := false
-
-			// Try to detect options - check position type, symbol format, etc.
-			// This needs to be implemented based on actual SnapTrade position structure
-			if isOption {
+			if options.Classify(position) {
 				optionPositions = append(optionPositions, position)
 			}
 		}
@@ -270,22 +399,24 @@ func GetOptionPositions(snapTradeClient *client.SnapTradeClient) fiber.Handler {
 	}
 }
 
-// isOptionPosition checks if a symbol represents an option
-// Options typically have formats like "AAPL230120C00150000" or contain specific patterns
-func isOptionPosition(symbol string) bool {
-	if symbol == "" {
-		return false
-	}
-	// Check for option-like patterns:
-	// - Contains "C" or "P" followed by numbers (call/put indicators)
-	// - Very long symbol strings (options are typically longer)
-	// - Contains date-like patterns
-	// This is a heuristic - adjust based on actual SnapTrade data
-	if len(symbol) > 15 {
-		// Options are typically longer than regular stock symbols
-		return true
+// ParseOptionSymbol handles GET /accounts/:accountId/options/:symbol/parse:
+// decodes an OCC-standard option symbol without needing a live position,
+// so a caller can sanity-check a symbol or build a contract label offline.
+func ParseOptionSymbol() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		symbol := c.Params("symbol")
+		parsed, ok := options.ParseSymbol(symbol)
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "symbol is not a valid OCC-standard option symbol",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"underlying": parsed.Underlying,
+			"expiration": parsed.Expiration.Format("2006-01-02"),
+			"right":      string(parsed.Right),
+			"strike":     parsed.Strike.String(),
+		})
 	}
-	// Check for call/put indicators in the symbol
-	// This is a simplified check - may need refinement
-	return false
 }
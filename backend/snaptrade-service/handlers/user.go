@@ -39,7 +39,7 @@ func CreateSnapTradeUser(snapTradeClient *client.SnapTradeClient) fiber.Handler
 			req.UserId = userId
 		}
 
-		result, err := snapTradeClient.CreateUser(req.UserId)
+		result, err := snapTradeClient.CreateUser(c.UserContext(), req.UserId)
 		if err != nil {
 			// Check if error is due to user already existing
 			errorMsg := err.Error()
@@ -116,7 +116,7 @@ func DeleteSnapTradeUser(snapTradeClient *client.SnapTradeClient) fiber.Handler
 			})
 		}
 
-		err := snapTradeClient.DeleteUser(userId, userSecret)
+		err := snapTradeClient.DeleteUser(c.UserContext(), userId, userSecret)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error":   "Failed to delete SnapTrade user",
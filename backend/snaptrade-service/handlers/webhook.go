@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"snaptrade-service/webhook"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SnapTradeWebhook verifies and dispatches asynchronous SnapTrade events
+// (USER_REGISTERED, CONNECTION_BROKEN, ACCOUNT_HOLDINGS_UPDATED, etc.)
+// posted to POST /api/v1/webhooks/snaptrade.
+func SnapTradeWebhook(dispatcher *webhook.Dispatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		body := c.Body()
+
+		signature := c.Get("X-SnapTrade-Signature")
+		if err := webhook.VerifySignature(body, signature); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "invalid webhook signature",
+			})
+		}
+
+		var event webhook.Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid webhook payload",
+			})
+		}
+
+		if event.ID == "" || event.Type == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "webhook payload missing id or eventType",
+			})
+		}
+
+		if err := dispatcher.Dispatch(c.UserContext(), event, string(body)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"received": true,
+		})
+	}
+}
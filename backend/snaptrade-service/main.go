@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"snaptrade-service/client"
 	"snaptrade-service/handlers"
+	"snaptrade-service/middleware"
+	"snaptrade-service/provider"
+	"snaptrade-service/provider/plaid"
+	snaptradeprovider "snaptrade-service/provider/snaptrade"
+	"snaptrade-service/services"
+	"snaptrade-service/stream"
+	"snaptrade-service/webhook"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/websocket/v2"
 	"github.com/joho/godotenv"
 )
 
@@ -25,6 +34,35 @@ func main() {
 		log.Fatalf("Failed to initialize SnapTrade client: %v", err)
 	}
 
+	// Initialize Supabase-backed services. The webhook dispatcher and the
+	// SnapTrade user-resolution middleware both need persisted state, so a
+	// working database is required to serve the connection/account routes.
+	db, err := services.NewDatabaseService()
+	if err != nil {
+		log.Fatalf("Failed to initialize database service: %v", err)
+	}
+	requireSnapTradeUser := middleware.RequireSnapTradeUser(db)
+	userCreationRateLimit := middleware.RequireUserCreationRateLimit()
+	requireIdempotencyKey := middleware.RequireIdempotencyKey(db)
+
+	// Brokerage-aggregator registry. SnapTrade is the default provider for
+	// requests that don't specify one; Plaid is registered as a stub ahead
+	// of its real integration landing.
+	providers := provider.NewRegistry("snaptrade")
+	defaultProvider := snaptradeprovider.New(snapTradeClient)
+	providers.Register(defaultProvider)
+	providers.Register(plaid.New())
+
+	sync := services.NewSyncService(db)
+	dispatcher := webhook.NewDispatcher(db, sync, defaultProvider)
+
+	// Periodically re-sync accounts whose cache has gone stale, so reads
+	// from GetHoldings/GetTransactions don't silently go arbitrarily old.
+	scheduler := services.NewSyncScheduler(sync, db, services.SyncTTLFromEnv(), services.SyncIntervalFromEnv())
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go scheduler.Run(schedulerCtx, defaultProvider)
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -57,24 +95,45 @@ func main() {
 	// API routes
 	api := app.Group("/api/v1")
 
-	// User management
-	api.Post("/users", handlers.CreateSnapTradeUser(snapTradeClient))
-	api.Get("/users/:userId", handlers.GetSnapTradeUser(snapTradeClient))
+	// User management. Rate limited per-IP since these otherwise have no
+	// protection against enumeration or creation abuse.
+	api.Post("/users", userCreationRateLimit, requireIdempotencyKey, handlers.CreateSnapTradeUser(snapTradeClient))
+	api.Get("/users/:userId", userCreationRateLimit, handlers.GetSnapTradeUser(snapTradeClient))
 
 	// Connection management
-	api.Post("/connections/initiate", handlers.InitiateConnection(snapTradeClient))
-	api.Get("/connections/:connectionId/status", handlers.GetConnectionStatus(snapTradeClient))
-	api.Get("/connections", handlers.ListConnections(snapTradeClient))
-	api.Delete("/connections/:connectionId", handlers.DeleteConnection(snapTradeClient))
-
-	// Account management
-	api.Get("/accounts", handlers.ListAccounts(snapTradeClient))
-	api.Get("/accounts/:accountId", handlers.GetAccountDetail(snapTradeClient))
-	api.Post("/accounts/sync", handlers.SyncAccounts(snapTradeClient))
-
-	// Transaction and holdings
-	api.Get("/accounts/:accountId/transactions", handlers.GetTransactions(snapTradeClient))
-	api.Get("/accounts/:accountId/holdings", handlers.GetHoldings(snapTradeClient))
+	connections := api.Group("/connections", requireSnapTradeUser)
+	connections.Post("/initiate", handlers.InitiateConnection(snapTradeClient))
+	connections.Get("/:connectionId/status", handlers.GetConnectionStatus(snapTradeClient))
+	connections.Get("/", handlers.ListConnections(snapTradeClient))
+	connections.Delete("/:connectionId", handlers.DeleteConnection(snapTradeClient))
+
+	// Account management, transactions, and holdings
+	accounts := api.Group("/accounts", requireSnapTradeUser)
+	accounts.Get("/", handlers.ListAccounts(snapTradeClient))
+	accounts.Get("/:accountId", handlers.GetAccountDetail(snapTradeClient))
+	accounts.Post("/sync", handlers.SyncAccounts(defaultProvider, sync))
+	accounts.Get("/:accountId/transactions", handlers.GetTransactions(snapTradeClient, sync))
+	accounts.Get("/:accountId/holdings", handlers.GetHoldings(snapTradeClient, sync))
+	accounts.Get("/:accountId/options", handlers.GetOptionPositions(snapTradeClient))
+	accounts.Get("/:accountId/options/:symbol/parse", handlers.ParseOptionSymbol())
+
+	// Provider-neutral aggregator routes, keyed by the ":provider" path
+	// segment (falls back to X-Provider header, then the registry default).
+	providerRoutes := api.Group("/providers/:provider", requireSnapTradeUser)
+	providerRoutes.Get("/accounts", handlers.ProviderListAccounts(providers))
+	providerRoutes.Get("/accounts/:accountId/holdings", handlers.ProviderGetHoldings(providers))
+	providerRoutes.Get("/accounts/:accountId/transactions", handlers.ProviderGetTransactions(providers))
+	providerRoutes.Get("/connections", handlers.ProviderListConnections(providers))
+	providerRoutes.Delete("/connections/:connectionId", handlers.ProviderDeleteConnection(providers))
+
+	// Webhooks
+	api.Post("/webhooks/snaptrade", handlers.SnapTradeWebhook(dispatcher))
+
+	// Streaming market data: position valuations and fills pushed to the
+	// browser over WebSocket, polling a pluggable quote provider.
+	streamHub := stream.NewHub()
+	quoteProvider := stream.NewHoldingsQuoteProvider(sync)
+	api.Get("/stream", stream.RequireUpgrade(db), websocket.New(stream.Handler(streamHub, sync, quoteProvider, stream.PollIntervalFromEnv())))
 
 	// Get port from environment or default
 	port := os.Getenv("PORT")
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"snaptrade-service/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// idempotencyKeyTTL mirrors the main Tradistry backend's IdempotencyMiddleware.
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyClaimTTL bounds how long a key stays reserved as "in flight"
+// before another request is allowed to retry it - comfortably longer than
+// any real handler should take, so a crashed request doesn't wedge the key
+// until the full response TTL expires.
+const idempotencyClaimTTL = 30 * time.Second
+
+// uniqueViolationCode is the Postgres SQLSTATE Postgrest reports when an
+// insert collides with a unique constraint - here, the (user_id, key)
+// constraint idempotency_keys already relies on for Upsert's onConflict.
+const uniqueViolationCode = "23505"
+
+type idempotencyRecord struct {
+	UserID       string    `json:"user_id"`
+	Key          string    `json:"key"`
+	RequestHash  string    `json:"request_hash"`
+	InFlight     bool      `json:"in_flight"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RequireIdempotencyKey makes the handler it wraps safe to retry: a caller
+// resending the same Idempotency-Key header and body gets back the
+// original response instead of re-executing the handler (e.g.
+// CreateSnapTradeUser, which would otherwise try to create the same
+// SnapTrade user twice on a client retry or an at-least-once webhook
+// redelivery). Requests without the header pass through unchanged.
+//
+// The first request for a key atomically claims it - a plain insert that
+// relies on the idempotency_keys (user_id, key) unique constraint to fail
+// for everyone else - before the handler runs, so two concurrent requests
+// with the same key can't both slip past a check-then-store race and both
+// execute. The loser of the race sees the in-flight claim and is told to
+// back off instead of re-running the handler.
+func RequireIdempotencyKey(db *services.DatabaseService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Idempotency-Key")
+		if key == "" {
+			return c.Next()
+		}
+
+		userID := c.Get("X-User-Id")
+		if userID == "" {
+			userID = "ip:" + c.IP()
+		}
+		requestHash := hashRequestBody(c.Body())
+
+		claim := idempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			InFlight:    true,
+			ExpiresAt:   time.Now().Add(idempotencyClaimTTL),
+		}
+
+		if err := db.Insert(c.Context(), "idempotency_keys", claim); err != nil {
+			if !isUniqueViolation(err) {
+				// Can't tell whether the key is already claimed; fail open
+				// rather than block the request on a database error.
+				return c.Next()
+			}
+
+			var existing []idempotencyRecord
+			if err := db.Select(c.Context(), "idempotency_keys", "*", map[string]interface{}{
+				"user_id": userID,
+				"key":     key,
+			}, &existing); err != nil || len(existing) == 0 {
+				return c.Next()
+			}
+
+			record := existing[0]
+			if record.RequestHash != requestHash {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Idempotency key was already used with a different request body",
+					"code":  "IDEMPOTENCY_KEY_CONFLICT",
+				})
+			}
+			if record.InFlight && record.ExpiresAt.After(time.Now()) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "A request with this idempotency key is already in progress",
+					"code":  "IDEMPOTENCY_KEY_IN_PROGRESS",
+				})
+			}
+			if !record.InFlight && record.ExpiresAt.After(time.Now()) {
+				c.Status(record.StatusCode)
+				return c.Send([]byte(record.ResponseBody))
+			}
+
+			// The previous claim expired (crashed handler or a stale
+			// in-flight marker) - take it over rather than wedge the key
+			// forever.
+			_ = db.Upsert(c.Context(), "idempotency_keys", claim, "user_id,key")
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		record := idempotencyRecord{
+			UserID:       userID,
+			Key:          key,
+			RequestHash:  requestHash,
+			InFlight:     false,
+			StatusCode:   c.Response().StatusCode(),
+			ResponseBody: string(c.Response().Body()),
+			ExpiresAt:    time.Now().Add(idempotencyKeyTTL),
+		}
+		_ = db.Upsert(context.Background(), "idempotency_keys", record, "user_id,key")
+
+		return nil
+	}
+}
+
+// isUniqueViolation reports whether err is the Postgrest-wrapped error for
+// a unique-constraint collision, i.e. another request already holds the
+// claim on this (user_id, key).
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), uniqueViolationCode)
+}
+
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/time/rate"
+)
+
+// ipRateLimiter hands out a golang.org/x/time/rate.Limiter per client IP,
+// lazily creating one on first sight. It's deliberately simple (no
+// eviction) since this guards a handful of low-traffic, abuse-sensitive
+// routes rather than the whole API surface.
+type ipRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	rps      rate.Limit
+	burst    int
+}
+
+func newIPRateLimiter(rps float64, burst int) *ipRateLimiter {
+	return &ipRateLimiter{
+		limiters: make(map[string]*rate.Limiter),
+		rps:      rate.Limit(rps),
+		burst:    burst,
+	}
+}
+
+func (l *ipRateLimiter) allow(ip string) bool {
+	l.mu.Lock()
+	lim, ok := l.limiters[ip]
+	if !ok {
+		lim = rate.NewLimiter(l.rps, l.burst)
+		l.limiters[ip] = lim
+	}
+	l.mu.Unlock()
+
+	return lim.Allow()
+}
+
+// RequireUserCreationRateLimit throttles per-IP access to the SnapTrade
+// user-creation and lookup handlers, which previously had no protection
+// against enumeration or abuse (unlike outbound calls to SnapTrade itself,
+// which already go through client.Doer's own limiter). Configured from
+// USER_RATE_LIMIT_RPS/USER_RATE_LIMIT_BURST, mirroring the
+// SNAPTRADE_RATE_LIMIT_RPS/SNAPTRADE_RATE_LIMIT_BURST naming client.Doer
+// uses for its own limiter.
+func RequireUserCreationRateLimit() fiber.Handler {
+	limiter := newIPRateLimiter(floatFromEnv("USER_RATE_LIMIT_RPS", 1), intFromEnv("USER_RATE_LIMIT_BURST", 5))
+
+	return func(c *fiber.Ctx) error {
+		if !limiter.allow(c.IP()) {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too many requests, please try again later",
+			})
+		}
+		return c.Next()
+	}
+}
+
+func floatFromEnv(key string, defaultValue float64) float64 {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil {
+			return v
+		}
+	}
+	return defaultValue
+}
+
+func intFromEnv(key string, defaultValue int) int {
+	if raw := os.Getenv(key); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil {
+			return v
+		}
+	}
+	return defaultValue
+}
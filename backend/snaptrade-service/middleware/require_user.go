@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"snaptrade-service/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type snapTradeUserRow struct {
+	UserID     string `json:"user_id"`
+	UserSecret string `json:"user_secret"`
+}
+
+// RequireSnapTradeUser resolves the caller's SnapTrade user secret from
+// Supabase rather than trusting a client-supplied X-User-Secret header. It
+// extracts X-User-Id, looks up the persisted secret in the snaptrade_users
+// table, 404s if the user doesn't exist, and stashes userId/userSecret in
+// c.Locals for downstream handlers.
+func RequireSnapTradeUser(db *services.DatabaseService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId := c.Get("X-User-Id")
+		if userId == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing user ID",
+			})
+		}
+
+		var rows []snapTradeUserRow
+		err := db.Select(c.UserContext(), "snaptrade_users", "user_id,user_secret", map[string]interface{}{
+			"user_id": userId,
+		}, &rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve SnapTrade user",
+			})
+		}
+
+		if len(rows) == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "SnapTrade user not found",
+			})
+		}
+
+		c.Locals("userId", rows[0].UserID)
+		c.Locals("userSecret", rows[0].UserSecret)
+
+		return c.Next()
+	}
+}
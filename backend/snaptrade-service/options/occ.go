@@ -0,0 +1,86 @@
+// Package options classifies SnapTrade positions as equity or options and
+// parses OCC-standard option symbols (e.g. "AAPL230120C00150000"). It
+// replaces the symbol-length heuristic that used to live in
+// handlers.isOptionPosition.
+package options
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	snaptrade "github.com/passiv/snaptrade-sdks/sdks/go"
+	"github.com/shopspring/decimal"
+)
+
+// occPattern matches an OCC-standard option symbol: a 1-6 character
+// underlying root, a 6-digit YYMMDD expiration, a C/P right indicator,
+// and an 8-digit strike price in thousandths of a dollar. Mini-options
+// (10 shares deliverable instead of 100) use this exact same format, so
+// they parse like any other contract - the smaller deliverable isn't
+// recoverable from the symbol alone.
+var occPattern = regexp.MustCompile(`^([A-Z]{1,6})(\d{6})([CP])(\d{8})$`)
+
+// ParsedOption is the decoded form of an OCC-standard option symbol.
+type ParsedOption struct {
+	Underlying string
+	Expiration time.Time
+	Right      rune
+	Strike     decimal.Decimal
+}
+
+// ParseOCC parses symbol as an OCC-standard option symbol.
+func ParseOCC(symbol string) (ParsedOption, bool) {
+	m := occPattern.FindStringSubmatch(symbol)
+	if m == nil {
+		return ParsedOption{}, false
+	}
+
+	expiration, err := time.Parse("060102", m[2])
+	if err != nil {
+		return ParsedOption{}, false
+	}
+
+	strikeThousandths, err := strconv.ParseInt(m[4], 10, 64)
+	if err != nil {
+		return ParsedOption{}, false
+	}
+
+	return ParsedOption{
+		Underlying: m[1],
+		Expiration: expiration,
+		Right:      rune(m[3][0]),
+		Strike:     decimal.New(strikeThousandths, -3),
+	}, true
+}
+
+// optionTypeCodes are the SecurityType.Code values SnapTrade uses for
+// option contracts, lowercased for case-insensitive comparison.
+var optionTypeCodes = map[string]bool{
+	"option": true,
+	"opt":    true,
+}
+
+// Classify reports whether position is an option contract. It trusts
+// SnapTrade's own SecurityType.Code when present, and otherwise falls
+// back to parsing the position's symbol as OCC - some brokerages report
+// options under a generic or missing security type.
+func Classify(position snaptrade.Position) bool {
+	positionSymbol := position.GetSymbol()
+	symbol := positionSymbol.GetSymbol()
+
+	if code := symbol.Type.Code; code != nil && optionTypeCodes[strings.ToLower(*code)] {
+		return true
+	}
+
+	_, ok := ParseOCC(symbol.Symbol)
+	return ok
+}
+
+// ParseSymbol parses a raw symbol string as an OCC option contract,
+// without needing a full SnapTrade position. Used by the
+// /accounts/:accountId/options/:symbol/parse endpoint.
+func ParseSymbol(symbol string) (ParsedOption, bool) {
+	return ParseOCC(symbol)
+}
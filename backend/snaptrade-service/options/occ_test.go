@@ -0,0 +1,110 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseOCC(t *testing.T) {
+	cases := []struct {
+		name       string
+		symbol     string
+		underlying string
+		expiration string // YYYY-MM-DD
+		right      rune
+		strike     string
+	}{
+		{
+			name:       "weekly expiration",
+			symbol:     "SPY240119C00470000",
+			underlying: "SPY",
+			expiration: "2024-01-19",
+			right:      'C',
+			strike:     "470",
+		},
+		{
+			name:       "LEAPS expiration over a year out",
+			symbol:     "AAPL260116C00200000",
+			underlying: "AAPL",
+			expiration: "2026-01-16",
+			right:      'C',
+			strike:     "200",
+		},
+		{
+			// Mini-options (10 shares deliverable instead of 100) are
+			// indistinguishable from a standard contract at the symbol
+			// level - same root, expiration, right, and strike format.
+			name:       "mini-option parses like any other contract",
+			symbol:     "QQQ230120C00150000",
+			underlying: "QQQ",
+			expiration: "2023-01-20",
+			right:      'C',
+			strike:     "150",
+		},
+		{
+			name:       "non-standard single-character root",
+			symbol:     "F210618P00010000",
+			underlying: "F",
+			expiration: "2021-06-18",
+			right:      'P',
+			strike:     "10",
+		},
+		{
+			name:       "non-standard six-character root",
+			symbol:     "ABCDEF230616C00025000",
+			underlying: "ABCDEF",
+			expiration: "2023-06-16",
+			right:      'C',
+			strike:     "25",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ParseOCC(tc.symbol)
+			if !ok {
+				t.Fatalf("ParseOCC(%q) returned ok=false, want true", tc.symbol)
+			}
+
+			wantExpiration, err := time.Parse("2006-01-02", tc.expiration)
+			if err != nil {
+				t.Fatalf("invalid test expiration %q: %v", tc.expiration, err)
+			}
+			wantStrike, err := decimal.NewFromString(tc.strike)
+			if err != nil {
+				t.Fatalf("invalid test strike %q: %v", tc.strike, err)
+			}
+
+			if got.Underlying != tc.underlying {
+				t.Errorf("Underlying = %q, want %q", got.Underlying, tc.underlying)
+			}
+			if !got.Expiration.Equal(wantExpiration) {
+				t.Errorf("Expiration = %v, want %v", got.Expiration, wantExpiration)
+			}
+			if got.Right != tc.right {
+				t.Errorf("Right = %q, want %q", got.Right, tc.right)
+			}
+			if !got.Strike.Equal(wantStrike) {
+				t.Errorf("Strike = %s, want %s", got.Strike, wantStrike)
+			}
+		})
+	}
+}
+
+func TestParseOCC_RejectsNonOptionSymbols(t *testing.T) {
+	cases := []string{
+		"AAPL",
+		"",
+		"TOOLONGROOT230120C00150000",
+		"AAPL230120X00150000",
+		"AAPL23012C00150000",
+	}
+
+	for _, symbol := range cases {
+		if _, ok := ParseOCC(symbol); ok {
+			t.Errorf("ParseOCC(%q) returned ok=true, want false", symbol)
+		}
+	}
+}
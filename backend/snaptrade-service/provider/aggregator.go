@@ -0,0 +1,85 @@
+// Package provider defines a brokerage-aggregator-neutral interface so
+// handlers can work against SnapTrade, Plaid, or any other aggregator
+// without depending on their SDKs directly.
+package provider
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotImplemented is returned by provider methods that a given aggregator
+// doesn't support yet, e.g. a stub implementation under active development.
+var ErrNotImplemented = errors.New("provider: not implemented")
+
+// UserCredentials is returned when an aggregator registers a new end user.
+type UserCredentials struct {
+	UserID     string `json:"user_id"`
+	UserSecret string `json:"user_secret"`
+}
+
+// Connection is a normalized brokerage connection/authorization.
+type Connection struct {
+	ID          string `json:"id"`
+	BrokerageID string `json:"brokerage_id"`
+	Name        string `json:"name,omitempty"`
+	Disabled    bool   `json:"disabled"`
+}
+
+// Account is a normalized brokerage account.
+type Account struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Number          string  `json:"number,omitempty"`
+	Institution     string  `json:"institution,omitempty"`
+	ConnectionID    string  `json:"connection_id,omitempty"`
+	TotalBalance    float64 `json:"total_balance"`
+	BalanceCurrency string  `json:"balance_currency,omitempty"`
+}
+
+// Holding is a normalized equity/position held in an account.
+type Holding struct {
+	Symbol               string  `json:"symbol"`
+	Description          string  `json:"description,omitempty"`
+	Quantity             float64 `json:"quantity"`
+	Price                float64 `json:"price"`
+	AveragePurchasePrice float64 `json:"average_purchase_price,omitempty"`
+	Currency             string  `json:"currency,omitempty"`
+}
+
+// Activity is a normalized transaction/activity line on an account.
+type Activity struct {
+	ID             string  `json:"id"`
+	AccountID      string  `json:"account_id,omitempty"`
+	Type           string  `json:"type"`
+	Symbol         string  `json:"symbol,omitempty"`
+	Quantity       float64 `json:"quantity"`
+	Price          float64 `json:"price"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency,omitempty"`
+	Description    string  `json:"description,omitempty"`
+	TradeDate      string  `json:"trade_date,omitempty"`
+	SettlementDate string  `json:"settlement_date,omitempty"`
+}
+
+// Aggregator is the provider-neutral surface handlers call into. Every
+// method accepts the caller's end-user credentials for that aggregator
+// (userId/userSecret for SnapTrade, an access token for Plaid, etc.) - the
+// concrete implementation decides how to use them.
+type Aggregator interface {
+	// Name identifies the provider, e.g. "snaptrade" or "plaid". It doubles
+	// as the registry key and the path segment/X-Provider header value.
+	Name() string
+
+	CreateUser(ctx context.Context, userId string) (UserCredentials, error)
+	DeleteUser(ctx context.Context, userId, userSecret string) error
+
+	InitiateConnection(ctx context.Context, userId, userSecret, brokerageId, connectionType string) (redirectURL string, err error)
+	GetConnectionStatus(ctx context.Context, userId, userSecret, connectionId string) (Connection, error)
+	ListConnections(ctx context.Context, userId, userSecret string) ([]Connection, error)
+	DeleteConnection(ctx context.Context, userId, userSecret, connectionId string) error
+
+	ListAccounts(ctx context.Context, userId, userSecret string) ([]Account, error)
+	GetHoldings(ctx context.Context, userId, userSecret, accountId string) ([]Holding, error)
+	GetTransactions(ctx context.Context, userId, userSecret, accountId string, startDate, endDate *string) ([]Activity, error)
+}
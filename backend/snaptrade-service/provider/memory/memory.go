@@ -0,0 +1,130 @@
+// Package memory is an in-memory provider.Aggregator fake for tests -
+// nothing is persisted and nothing calls out over the network.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"snaptrade-service/provider"
+)
+
+// Provider is a fake aggregator backed by in-process maps, keyed by
+// userId+userSecret. It's meant for handler/unit tests, not production use.
+type Provider struct {
+	mu          sync.Mutex
+	users       map[string]provider.UserCredentials
+	connections map[string][]provider.Connection
+	accounts    map[string][]provider.Account
+	holdings    map[string][]provider.Holding
+	activities  map[string][]provider.Activity
+}
+
+// New returns an empty in-memory provider.
+func New() *Provider {
+	return &Provider{
+		users:       make(map[string]provider.UserCredentials),
+		connections: make(map[string][]provider.Connection),
+		accounts:    make(map[string][]provider.Account),
+		holdings:    make(map[string][]provider.Holding),
+		activities:  make(map[string][]provider.Activity),
+	}
+}
+
+func (p *Provider) Name() string { return "memory" }
+
+func (p *Provider) key(userId, userSecret string) string {
+	return userId + ":" + userSecret
+}
+
+// Seed installs fixture data for userId/userSecret, for use in tests that
+// want ListAccounts/GetHoldings/GetTransactions to return known values.
+func (p *Provider) Seed(userId, userSecret string, accounts []provider.Account, holdings map[string][]provider.Holding, activities map[string][]provider.Activity) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := p.key(userId, userSecret)
+	p.accounts[key] = accounts
+	for accountId, h := range holdings {
+		p.holdings[key+":"+accountId] = h
+	}
+	for accountId, a := range activities {
+		p.activities[key+":"+accountId] = a
+	}
+}
+
+func (p *Provider) CreateUser(ctx context.Context, userId string) (provider.UserCredentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	creds := provider.UserCredentials{UserID: userId, UserSecret: fmt.Sprintf("fake-secret-%s", userId)}
+	p.users[userId] = creds
+	return creds, nil
+}
+
+func (p *Provider) DeleteUser(ctx context.Context, userId, userSecret string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.users, userId)
+	return nil
+}
+
+func (p *Provider) InitiateConnection(ctx context.Context, userId, userSecret, brokerageId, connectionType string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := p.key(userId, userSecret)
+	connectionId := fmt.Sprintf("conn-%d", len(p.connections[key])+1)
+	p.connections[key] = append(p.connections[key], provider.Connection{
+		ID:          connectionId,
+		BrokerageID: brokerageId,
+	})
+	return fmt.Sprintf("https://example.invalid/connect/%s", connectionId), nil
+}
+
+func (p *Provider) GetConnectionStatus(ctx context.Context, userId, userSecret, connectionId string) (provider.Connection, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, conn := range p.connections[p.key(userId, userSecret)] {
+		if conn.ID == connectionId {
+			return conn, nil
+		}
+	}
+	return provider.Connection{}, fmt.Errorf("connection not found")
+}
+
+func (p *Provider) ListConnections(ctx context.Context, userId, userSecret string) ([]provider.Connection, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.connections[p.key(userId, userSecret)], nil
+}
+
+func (p *Provider) DeleteConnection(ctx context.Context, userId, userSecret, connectionId string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := p.key(userId, userSecret)
+	conns := p.connections[key]
+	for i, conn := range conns {
+		if conn.ID == connectionId {
+			p.connections[key] = append(conns[:i], conns[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("connection not found")
+}
+
+func (p *Provider) ListAccounts(ctx context.Context, userId, userSecret string) ([]provider.Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.accounts[p.key(userId, userSecret)], nil
+}
+
+func (p *Provider) GetHoldings(ctx context.Context, userId, userSecret, accountId string) ([]provider.Holding, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.holdings[p.key(userId, userSecret)+":"+accountId], nil
+}
+
+func (p *Provider) GetTransactions(ctx context.Context, userId, userSecret, accountId string, startDate, endDate *string) ([]provider.Activity, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.activities[p.key(userId, userSecret)+":"+accountId], nil
+}
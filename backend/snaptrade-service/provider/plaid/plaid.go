@@ -0,0 +1,57 @@
+// Package plaid is a placeholder provider.Aggregator for Plaid. None of the
+// Plaid SDK wiring exists yet - every method returns provider.ErrNotImplemented
+// so the provider can be registered and routed to ahead of the real
+// integration landing.
+package plaid
+
+import (
+	"context"
+
+	"snaptrade-service/provider"
+)
+
+// Provider is an unimplemented provider.Aggregator for Plaid.
+type Provider struct{}
+
+// New returns a Plaid provider stub.
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string { return "plaid" }
+
+func (p *Provider) CreateUser(ctx context.Context, userId string) (provider.UserCredentials, error) {
+	return provider.UserCredentials{}, provider.ErrNotImplemented
+}
+
+func (p *Provider) DeleteUser(ctx context.Context, userId, userSecret string) error {
+	return provider.ErrNotImplemented
+}
+
+func (p *Provider) InitiateConnection(ctx context.Context, userId, userSecret, brokerageId, connectionType string) (string, error) {
+	return "", provider.ErrNotImplemented
+}
+
+func (p *Provider) GetConnectionStatus(ctx context.Context, userId, userSecret, connectionId string) (provider.Connection, error) {
+	return provider.Connection{}, provider.ErrNotImplemented
+}
+
+func (p *Provider) ListConnections(ctx context.Context, userId, userSecret string) ([]provider.Connection, error) {
+	return nil, provider.ErrNotImplemented
+}
+
+func (p *Provider) DeleteConnection(ctx context.Context, userId, userSecret, connectionId string) error {
+	return provider.ErrNotImplemented
+}
+
+func (p *Provider) ListAccounts(ctx context.Context, userId, userSecret string) ([]provider.Account, error) {
+	return nil, provider.ErrNotImplemented
+}
+
+func (p *Provider) GetHoldings(ctx context.Context, userId, userSecret, accountId string) ([]provider.Holding, error) {
+	return nil, provider.ErrNotImplemented
+}
+
+func (p *Provider) GetTransactions(ctx context.Context, userId, userSecret, accountId string, startDate, endDate *string) ([]provider.Activity, error) {
+	return nil, provider.ErrNotImplemented
+}
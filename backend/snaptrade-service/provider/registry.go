@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry looks up an Aggregator by its provider name, e.g. the
+// ":provider" path segment or X-Provider header on an incoming request.
+type Registry struct {
+	mu          sync.RWMutex
+	providers   map[string]Aggregator
+	defaultName string
+}
+
+// NewRegistry creates an empty Registry. defaultName is used by Default and
+// by callers that want a provider when none was specified on the request.
+func NewRegistry(defaultName string) *Registry {
+	return &Registry{
+		providers:   make(map[string]Aggregator),
+		defaultName: defaultName,
+	}
+}
+
+// Register adds p to the registry under p.Name(), replacing any existing
+// provider registered under that name.
+func (r *Registry) Register(p Aggregator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[p.Name()] = p
+}
+
+// Get returns the provider registered under name.
+func (r *Registry) Get(name string) (Aggregator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Default returns the provider registered under the registry's default
+// name, which is used whenever a request doesn't specify a provider.
+func (r *Registry) Default() (Aggregator, bool) {
+	return r.Get(r.defaultName)
+}
+
+// Resolve returns the named provider, falling back to the default provider
+// when name is empty. It returns an error identifying the requested name if
+// no such provider is registered.
+func (r *Registry) Resolve(name string) (Aggregator, error) {
+	if name == "" {
+		if p, ok := r.Default(); ok {
+			return p, nil
+		}
+		return nil, fmt.Errorf("provider: no default provider registered")
+	}
+	if p, ok := r.Get(name); ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("provider: unknown provider %q", name)
+}
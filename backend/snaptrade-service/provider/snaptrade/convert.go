@@ -0,0 +1,50 @@
+package snaptrade
+
+import (
+	"snaptrade-service/provider"
+
+	snaptradesdk "github.com/passiv/snaptrade-sdks/sdks/go"
+)
+
+func toConnection(auth snaptradesdk.BrokerageAuthorization) provider.Connection {
+	brokerage := auth.GetBrokerage()
+	return provider.Connection{
+		ID:          auth.GetId(),
+		BrokerageID: brokerage.GetId(),
+		Name:        auth.GetName(),
+		Disabled:    auth.GetDisabled(),
+	}
+}
+
+func toAccount(account snaptradesdk.Account) provider.Account {
+	balance := account.GetBalance()
+	total := balance.GetTotal()
+	return provider.Account{
+		ID:              account.GetId(),
+		Name:            account.GetName(),
+		Number:          account.GetNumber(),
+		Institution:     account.GetInstitutionName(),
+		ConnectionID:    account.GetBrokerageAuthorization(),
+		TotalBalance:    float64(total.GetAmount()),
+		BalanceCurrency: total.GetCurrency(),
+	}
+}
+
+func toActivity(activity snaptradesdk.UniversalActivity) provider.Activity {
+	account := activity.GetAccount()
+	symbol := activity.GetSymbol()
+	currency := activity.GetCurrency()
+	return provider.Activity{
+		ID:             activity.GetId(),
+		AccountID:      account.GetId(),
+		Type:           activity.GetType(),
+		Symbol:         symbol.GetSymbol(),
+		Quantity:       float64(activity.GetUnits()),
+		Price:          float64(activity.GetPrice()),
+		Amount:         float64(activity.GetAmount()),
+		Currency:       currency.GetCode(),
+		Description:    activity.GetDescription(),
+		TradeDate:      activity.GetTradeDate().Format("2006-01-02"),
+		SettlementDate: activity.GetSettlementDate().Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,123 @@
+// Package snaptrade adapts client.SnapTradeClient to the provider.Aggregator
+// interface, translating SnapTrade SDK types into provider-neutral DTOs.
+package snaptrade
+
+import (
+	"context"
+
+	"snaptrade-service/client"
+	"snaptrade-service/provider"
+)
+
+// Provider implements provider.Aggregator on top of the SnapTrade SDK.
+type Provider struct {
+	client *client.SnapTradeClient
+}
+
+// New wraps an existing SnapTradeClient as a provider.Aggregator.
+func New(c *client.SnapTradeClient) *Provider {
+	return &Provider{client: c}
+}
+
+func (p *Provider) Name() string { return "snaptrade" }
+
+func (p *Provider) CreateUser(ctx context.Context, userId string) (provider.UserCredentials, error) {
+	result, err := p.client.CreateUser(ctx, userId)
+	if err != nil {
+		return provider.UserCredentials{}, err
+	}
+	return provider.UserCredentials{
+		UserID:     result.GetUserId(),
+		UserSecret: result.GetUserSecret(),
+	}, nil
+}
+
+func (p *Provider) DeleteUser(ctx context.Context, userId, userSecret string) error {
+	return p.client.DeleteUser(ctx, userId, userSecret)
+}
+
+func (p *Provider) InitiateConnection(ctx context.Context, userId, userSecret, brokerageId, connectionType string) (string, error) {
+	redirect, err := p.client.GenerateConnectionPortalURL(ctx, userId, userSecret, brokerageId, connectionType)
+	if err != nil {
+		return "", err
+	}
+	redirectURI, _ := redirect.GetRedirectURIOk()
+	if redirectURI == nil {
+		return "", provider.ErrNotImplemented
+	}
+	return *redirectURI, nil
+}
+
+func (p *Provider) GetConnectionStatus(ctx context.Context, userId, userSecret, connectionId string) (provider.Connection, error) {
+	auth, err := p.client.GetConnectionStatus(ctx, userId, userSecret, connectionId)
+	if err != nil {
+		return provider.Connection{}, err
+	}
+	return toConnection(*auth), nil
+}
+
+func (p *Provider) ListConnections(ctx context.Context, userId, userSecret string) ([]provider.Connection, error) {
+	auths, err := p.client.ListConnections(ctx, userId, userSecret)
+	if err != nil {
+		return nil, err
+	}
+	connections := make([]provider.Connection, 0, len(auths))
+	for _, auth := range auths {
+		connections = append(connections, toConnection(auth))
+	}
+	return connections, nil
+}
+
+func (p *Provider) DeleteConnection(ctx context.Context, userId, userSecret, connectionId string) error {
+	return p.client.DeleteConnection(ctx, userId, userSecret, connectionId)
+}
+
+func (p *Provider) ListAccounts(ctx context.Context, userId, userSecret string) ([]provider.Account, error) {
+	accounts, err := p.client.ListAccounts(ctx, userId, userSecret)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]provider.Account, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, toAccount(account))
+	}
+	return result, nil
+}
+
+func (p *Provider) GetHoldings(ctx context.Context, userId, userSecret, accountId string) ([]provider.Holding, error) {
+	holdings, err := p.client.GetHoldings(ctx, userId, userSecret, accountId)
+	if err != nil {
+		return nil, err
+	}
+	if holdings == nil {
+		return nil, nil
+	}
+	positions := holdings.GetPositions()
+	result := make([]provider.Holding, 0, len(positions))
+	for _, position := range positions {
+		symbol := position.GetSymbol()
+		universalSymbol := symbol.GetSymbol()
+		currency := position.GetCurrency()
+		result = append(result, provider.Holding{
+			Symbol:               universalSymbol.GetSymbol(),
+			Description:          symbol.GetDescription(),
+			Quantity:             float64(position.GetUnits()),
+			Price:                float64(position.GetPrice()),
+			AveragePurchasePrice: float64(position.GetAveragePurchasePrice()),
+			Currency:             currency.GetCode(),
+		})
+	}
+	return result, nil
+}
+
+func (p *Provider) GetTransactions(ctx context.Context, userId, userSecret, accountId string, startDate, endDate *string) ([]provider.Activity, error) {
+	activities, err := p.client.GetTransactions(ctx, userId, userSecret, accountId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]provider.Activity, 0, len(activities))
+	for _, activity := range activities {
+		result = append(result, toActivity(activity))
+	}
+	return result, nil
+}
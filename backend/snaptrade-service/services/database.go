@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/supabase-community/supabase-go"
+)
+
+// DatabaseService gives the snaptrade-service read/write access to the
+// shared Supabase project, mirroring the pattern used by the main
+// Tradistry backend's services.DatabaseService.
+type DatabaseService struct {
+	client *supabase.Client
+}
+
+// NewDatabaseService creates a new database service instance from
+// SUPABASE_URL and SUPABASE_SERVICE_KEY.
+func NewDatabaseService() (*DatabaseService, error) {
+	url := os.Getenv("SUPABASE_URL")
+	serviceKey := os.Getenv("SUPABASE_SERVICE_KEY")
+
+	if url == "" || serviceKey == "" {
+		return nil, fmt.Errorf("SUPABASE_URL and SUPABASE_SERVICE_KEY must be set")
+	}
+
+	client, err := supabase.NewClient(url, serviceKey, &supabase.ClientOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create supabase client: %w", err)
+	}
+
+	return &DatabaseService{client: client}, nil
+}
+
+// Insert inserts a record into the specified table.
+func (db *DatabaseService) Insert(ctx context.Context, table string, data interface{}) error {
+	_, _, err := db.client.From(table).Insert(data, false, "", "", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to insert into %s: %w", table, err)
+	}
+	return nil
+}
+
+// Select performs a select query on the specified table.
+func (db *DatabaseService) Select(ctx context.Context, table string, columns string, filters map[string]interface{}, result interface{}) error {
+	query := db.client.From(table).Select(columns, "", false)
+
+	for key, value := range filters {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+
+	_, err := query.ExecuteTo(result)
+	if err != nil {
+		return fmt.Errorf("failed to select from %s: %w", table, err)
+	}
+	return nil
+}
+
+// SelectIn performs a select query on the specified table, filtered to
+// rows whose column is one of values.
+func (db *DatabaseService) SelectIn(ctx context.Context, table, columns, column string, values []string, result interface{}) error {
+	query := db.client.From(table).Select(columns, "", false).In(column, values)
+
+	_, err := query.ExecuteTo(result)
+	if err != nil {
+		return fmt.Errorf("failed to select from %s: %w", table, err)
+	}
+	return nil
+}
+
+// Upsert inserts or updates records in the specified table, resolving
+// conflicts on onConflict (a comma-separated list of column names).
+func (db *DatabaseService) Upsert(ctx context.Context, table string, data interface{}, onConflict string) error {
+	_, _, err := db.client.From(table).Upsert(data, onConflict, "", "").Execute()
+	if err != nil {
+		return fmt.Errorf("failed to upsert into %s: %w", table, err)
+	}
+	return nil
+}
+
+// Update updates records in the specified table.
+func (db *DatabaseService) Update(ctx context.Context, table string, data interface{}, filters map[string]interface{}) error {
+	query := db.client.From(table).Update(data, "", "")
+
+	for key, value := range filters {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+
+	_, _, err := query.Execute()
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", table, err)
+	}
+	return nil
+}
+
+// Delete deletes records from the specified table.
+func (db *DatabaseService) Delete(ctx context.Context, table string, filters map[string]interface{}) error {
+	query := db.client.From(table).Delete("", "")
+
+	for key, value := range filters {
+		query = query.Eq(key, fmt.Sprintf("%v", value))
+	}
+
+	_, _, err := query.Execute()
+	if err != nil {
+		return fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"snaptrade-service/provider"
+)
+
+// SyncScheduler periodically re-syncs accounts whose cached data is older
+// than a configurable TTL, so GetHoldings/GetTransactions keep serving
+// reasonably fresh data without every request hitting the aggregator.
+type SyncScheduler struct {
+	sync     *SyncService
+	db       *DatabaseService
+	ttl      time.Duration
+	interval time.Duration
+}
+
+// NewSyncScheduler creates a scheduler that considers an account stale once
+// its last_synced_at is older than ttl, checking every interval.
+func NewSyncScheduler(sync *SyncService, db *DatabaseService, ttl, interval time.Duration) *SyncScheduler {
+	return &SyncScheduler{sync: sync, db: db, ttl: ttl, interval: interval}
+}
+
+// DefaultSyncTTL and DefaultSyncInterval are used when SYNC_TTL_MS /
+// SYNC_INTERVAL_MS aren't set.
+const (
+	DefaultSyncTTL      = 15 * time.Minute
+	DefaultSyncInterval = 5 * time.Minute
+)
+
+// SyncTTLFromEnv reads SYNC_TTL_MS, falling back to DefaultSyncTTL.
+func SyncTTLFromEnv() time.Duration {
+	return durationFromEnvMs("SYNC_TTL_MS", DefaultSyncTTL)
+}
+
+// SyncIntervalFromEnv reads SYNC_INTERVAL_MS, falling back to DefaultSyncInterval.
+func SyncIntervalFromEnv() time.Duration {
+	return durationFromEnvMs("SYNC_INTERVAL_MS", DefaultSyncInterval)
+}
+
+func durationFromEnvMs(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	ms, err := strconv.Atoi(raw)
+	if err != nil || ms <= 0 {
+		return fallback
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Run blocks, re-syncing stale accounts against agg every interval until ctx
+// is canceled. Callers typically invoke it in a goroutine from main.
+func (s *SyncScheduler) Run(ctx context.Context, agg provider.Aggregator) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.syncStaleAccounts(ctx, agg)
+		}
+	}
+}
+
+func (s *SyncScheduler) syncStaleAccounts(ctx context.Context, agg provider.Aggregator) {
+	var accounts []StoredAccount
+	if err := s.db.Select(ctx, accountsTable, "*", map[string]interface{}{}, &accounts); err != nil {
+		fmt.Printf("sync scheduler: failed to list accounts: %v\n", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-s.ttl)
+	resynced := make(map[string]bool)
+	for _, account := range accounts {
+		if account.LastSyncedAt.After(cutoff) || resynced[account.UserID] {
+			continue
+		}
+
+		userSecret, err := s.userSecret(ctx, account.UserID)
+		if err != nil {
+			fmt.Printf("sync scheduler: skipping user %s: %v\n", account.UserID, err)
+			continue
+		}
+
+		if _, err := s.sync.SyncAccounts(ctx, agg, account.UserID, userSecret); err != nil {
+			fmt.Printf("sync scheduler: failed to resync user %s: %v\n", account.UserID, err)
+			continue
+		}
+		resynced[account.UserID] = true
+	}
+}
+
+func (s *SyncScheduler) userSecret(ctx context.Context, userId string) (string, error) {
+	var rows []struct {
+		UserSecret string `json:"user_secret"`
+	}
+	if err := s.db.Select(ctx, "snaptrade_users", "user_secret", map[string]interface{}{"user_id": userId}, &rows); err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("no stored user_secret for user %s", userId)
+	}
+	return rows[0].UserSecret, nil
+}
@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"snaptrade-service/provider"
+)
+
+const (
+	accountsTable   = "accounts"
+	holdingsTable   = "holdings"
+	activitiesTable = "activities"
+)
+
+// StoredAccount is the cached row for an account synced from an aggregator.
+type StoredAccount struct {
+	AccountID       string    `json:"account_id"`
+	UserID          string    `json:"user_id"`
+	Provider        string    `json:"provider"`
+	Name            string    `json:"name"`
+	Number          string    `json:"number,omitempty"`
+	Institution     string    `json:"institution,omitempty"`
+	ConnectionID    string    `json:"connection_id,omitempty"`
+	TotalBalance    float64   `json:"total_balance"`
+	BalanceCurrency string    `json:"balance_currency,omitempty"`
+	LastSyncedAt    time.Time `json:"last_synced_at"`
+}
+
+// StoredHolding is the cached row for a position synced from an aggregator.
+type StoredHolding struct {
+	AccountID            string    `json:"account_id"`
+	Symbol               string    `json:"symbol"`
+	Description          string    `json:"description,omitempty"`
+	Quantity             float64   `json:"quantity"`
+	Price                float64   `json:"price"`
+	AveragePurchasePrice float64   `json:"average_purchase_price,omitempty"`
+	Currency             string    `json:"currency,omitempty"`
+	SyncedAt             time.Time `json:"synced_at"`
+}
+
+// StoredActivity is the cached row for a transaction synced from an aggregator.
+type StoredActivity struct {
+	ActivityID     string  `json:"activity_id"`
+	AccountID      string  `json:"account_id"`
+	Type           string  `json:"type"`
+	Symbol         string  `json:"symbol,omitempty"`
+	Quantity       float64 `json:"quantity"`
+	Price          float64 `json:"price"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency,omitempty"`
+	Description    string  `json:"description,omitempty"`
+	TradeDate      string  `json:"trade_date,omitempty"`
+	SettlementDate string  `json:"settlement_date,omitempty"`
+}
+
+// SyncService fetches accounts, holdings, and transactions from a brokerage
+// aggregator and upserts normalized rows into Supabase, so handlers can
+// serve cached reads instead of hitting the aggregator on every request.
+type SyncService struct {
+	db *DatabaseService
+}
+
+// NewSyncService creates a SyncService backed by db.
+func NewSyncService(db *DatabaseService) *SyncService {
+	return &SyncService{db: db}
+}
+
+// SyncAccounts fetches every account for userId/userSecret and upserts the
+// account, its holdings, and an incremental window of its transactions.
+func (s *SyncService) SyncAccounts(ctx context.Context, agg provider.Aggregator, userId, userSecret string) ([]provider.Account, error) {
+	accounts, err := agg.ListAccounts(ctx, userId, userSecret)
+	if err != nil {
+		return nil, fmt.Errorf("sync: failed to list accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		if err := s.upsertAccount(ctx, agg.Name(), userId, account); err != nil {
+			return nil, err
+		}
+		if err := s.SyncHoldings(ctx, agg, userId, userSecret, account.ID); err != nil {
+			return nil, err
+		}
+		if err := s.SyncTransactions(ctx, agg, userId, userSecret, account.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.recordActionEvent(ctx, userId, "accounts_synced", fmt.Sprintf("%d accounts", len(accounts)))
+
+	return accounts, nil
+}
+
+// recordActionEvent writes a best-effort row to the action_events audit
+// table. A failure here shouldn't fail the sync itself, so the error is
+// dropped.
+func (s *SyncService) recordActionEvent(ctx context.Context, userId, action, target string) {
+	s.db.Insert(ctx, "action_events", map[string]interface{}{
+		"account_id": userId,
+		"action":     action,
+		"target":     target,
+		"created_at": time.Now(),
+	})
+}
+
+func (s *SyncService) upsertAccount(ctx context.Context, providerName, userId string, account provider.Account) error {
+	row := StoredAccount{
+		AccountID:       account.ID,
+		UserID:          userId,
+		Provider:        providerName,
+		Name:            account.Name,
+		Number:          account.Number,
+		Institution:     account.Institution,
+		ConnectionID:    account.ConnectionID,
+		TotalBalance:    account.TotalBalance,
+		BalanceCurrency: account.BalanceCurrency,
+		LastSyncedAt:    time.Now(),
+	}
+	if err := s.db.Upsert(ctx, accountsTable, row, "account_id"); err != nil {
+		return fmt.Errorf("sync: failed to upsert account %s: %w", account.ID, err)
+	}
+	return nil
+}
+
+// SyncHoldings refreshes the cached positions for a single account.
+func (s *SyncService) SyncHoldings(ctx context.Context, agg provider.Aggregator, userId, userSecret, accountId string) error {
+	holdings, err := agg.GetHoldings(ctx, userId, userSecret, accountId)
+	if err != nil {
+		return fmt.Errorf("sync: failed to get holdings for account %s: %w", accountId, err)
+	}
+
+	now := time.Now()
+	for _, holding := range holdings {
+		row := StoredHolding{
+			AccountID:            accountId,
+			Symbol:               holding.Symbol,
+			Description:          holding.Description,
+			Quantity:             holding.Quantity,
+			Price:                holding.Price,
+			AveragePurchasePrice: holding.AveragePurchasePrice,
+			Currency:             holding.Currency,
+			SyncedAt:             now,
+		}
+		if err := s.db.Upsert(ctx, holdingsTable, row, "account_id,symbol"); err != nil {
+			return fmt.Errorf("sync: failed to upsert holding %s/%s: %w", accountId, holding.Symbol, err)
+		}
+	}
+
+	return nil
+}
+
+// SyncTransactions fetches activities newer than the latest cached
+// trade_date for accountId (an incremental sync) and upserts them.
+func (s *SyncService) SyncTransactions(ctx context.Context, agg provider.Aggregator, userId, userSecret, accountId string) error {
+	startDate, err := s.latestTradeDate(ctx, accountId)
+	if err != nil {
+		return fmt.Errorf("sync: failed to determine incremental start date for account %s: %w", accountId, err)
+	}
+
+	var startDatePtr *string
+	if startDate != "" {
+		startDatePtr = &startDate
+	}
+
+	activities, err := agg.GetTransactions(ctx, userId, userSecret, accountId, startDatePtr, nil)
+	if err != nil {
+		return fmt.Errorf("sync: failed to get transactions for account %s: %w", accountId, err)
+	}
+
+	for _, activity := range activities {
+		row := StoredActivity{
+			ActivityID:     activity.ID,
+			AccountID:      accountId,
+			Type:           activity.Type,
+			Symbol:         activity.Symbol,
+			Quantity:       activity.Quantity,
+			Price:          activity.Price,
+			Amount:         activity.Amount,
+			Currency:       activity.Currency,
+			Description:    activity.Description,
+			TradeDate:      activity.TradeDate,
+			SettlementDate: activity.SettlementDate,
+		}
+		if err := s.db.Upsert(ctx, activitiesTable, row, "activity_id"); err != nil {
+			return fmt.Errorf("sync: failed to upsert activity %s: %w", activity.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SyncService) latestTradeDate(ctx context.Context, accountId string) (string, error) {
+	var rows []StoredActivity
+	if err := s.db.Select(ctx, activitiesTable, "trade_date", map[string]interface{}{"account_id": accountId}, &rows); err != nil {
+		return "", err
+	}
+
+	var latest string
+	for _, row := range rows {
+		if row.TradeDate > latest {
+			latest = row.TradeDate
+		}
+	}
+	return latest, nil
+}
+
+// CachedAccounts returns the last synced accounts for userId.
+func (s *SyncService) CachedAccounts(ctx context.Context, userId string) ([]StoredAccount, error) {
+	var accounts []StoredAccount
+	if err := s.db.Select(ctx, accountsTable, "*", map[string]interface{}{"user_id": userId}, &accounts); err != nil {
+		return nil, fmt.Errorf("sync: failed to read cached accounts for user %s: %w", userId, err)
+	}
+	return accounts, nil
+}
+
+// CachedHoldings returns the last synced positions for accountId.
+func (s *SyncService) CachedHoldings(ctx context.Context, accountId string) ([]StoredHolding, error) {
+	var holdings []StoredHolding
+	if err := s.db.Select(ctx, holdingsTable, "*", map[string]interface{}{"account_id": accountId}, &holdings); err != nil {
+		return nil, fmt.Errorf("sync: failed to read cached holdings for account %s: %w", accountId, err)
+	}
+	return holdings, nil
+}
+
+// LatestPrices returns the last cached price for each of symbols, keyed by
+// symbol. Symbols with no cached holding are omitted.
+func (s *SyncService) LatestPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	if len(symbols) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	var holdings []StoredHolding
+	if err := s.db.SelectIn(ctx, holdingsTable, "*", "symbol", symbols, &holdings); err != nil {
+		return nil, fmt.Errorf("sync: failed to read cached prices: %w", err)
+	}
+
+	prices := make(map[string]float64, len(holdings))
+	for _, h := range holdings {
+		prices[h.Symbol] = h.Price
+	}
+	return prices, nil
+}
+
+// ListActivities returns a cursor-paginated page of cached activities for
+// accountId, ordered by trade date then activity ID. cursor is the
+// activity_id of the last item returned by the previous page, or "" for the
+// first page. The returned nextCursor is "" once there are no more pages.
+func (s *SyncService) ListActivities(ctx context.Context, accountId, cursor string, limit int) ([]StoredActivity, string, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	var activities []StoredActivity
+	if err := s.db.Select(ctx, activitiesTable, "*", map[string]interface{}{"account_id": accountId}, &activities); err != nil {
+		return nil, "", fmt.Errorf("sync: failed to read cached activities for account %s: %w", accountId, err)
+	}
+
+	sort.Slice(activities, func(i, j int) bool {
+		if activities[i].TradeDate != activities[j].TradeDate {
+			return activities[i].TradeDate < activities[j].TradeDate
+		}
+		return activities[i].ActivityID < activities[j].ActivityID
+	})
+
+	start := 0
+	if cursor != "" {
+		for i, activity := range activities {
+			if activity.ActivityID == cursor {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	end := start + limit
+	if end > len(activities) {
+		end = len(activities)
+	}
+	if start > end {
+		start = end
+	}
+
+	page := activities[start:end]
+	nextCursor := ""
+	if end < len(activities) && len(page) > 0 {
+		nextCursor = page[len(page)-1].ActivityID
+	}
+
+	return page, nextCursor, nil
+}
@@ -0,0 +1,206 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"snaptrade-service/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// defaultPollInterval is how often the quote provider is polled when
+// STREAM_POLL_INTERVAL_MS isn't set.
+const defaultPollInterval = 5 * time.Second
+
+// PollIntervalFromEnv reads STREAM_POLL_INTERVAL_MS, falling back to
+// defaultPollInterval.
+func PollIntervalFromEnv() time.Duration {
+	raw := os.Getenv("STREAM_POLL_INTERVAL_MS")
+	if raw == "" {
+		return defaultPollInterval
+	}
+	ms, err := strconv.Atoi(raw)
+	if err != nil || ms <= 0 {
+		return defaultPollInterval
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// snapTradeUserRow mirrors middleware.snapTradeUserRow - kept as a separate
+// unexported type here since stream doesn't import middleware.
+type snapTradeUserRow struct {
+	UserID     string `json:"user_id"`
+	UserSecret string `json:"user_secret"`
+}
+
+// RequireUpgrade resolves the caller's SnapTrade user the same way
+// middleware.RequireSnapTradeUser does - X-User-Id looked up against the
+// snaptrade_users table, 404ing on an unknown user - rather than trusting
+// a client-supplied X-User-Secret header, which would let anyone who
+// knows or guesses a user id open the stream for that user's holdings and
+// quotes. It also requires the request to actually be a WebSocket
+// upgrade.
+func RequireUpgrade(db *services.DatabaseService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		userId := c.Get("X-User-Id")
+		if userId == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing user ID",
+			})
+		}
+
+		var rows []snapTradeUserRow
+		err := db.Select(c.UserContext(), "snaptrade_users", "user_id,user_secret", map[string]interface{}{
+			"user_id": userId,
+		}, &rows)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve SnapTrade user",
+			})
+		}
+
+		if len(rows) == 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "SnapTrade user not found",
+			})
+		}
+
+		c.Locals("userId", rows[0].UserID)
+		c.Locals("userSecret", rows[0].UserSecret)
+		return c.Next()
+	}
+}
+
+// Handler returns a websocket.New handler that streams quote/fill/pnl
+// frames for the connecting user: it subscribes to the hub, determines
+// which symbols to poll from the user's current holdings, and polls
+// quotes at interval until the connection closes.
+func Handler(hub *Hub, sync *services.SyncService, quotes QuoteProvider, interval time.Duration) func(*websocket.Conn) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return func(conn *websocket.Conn) {
+		userId, _ := conn.Locals("userId").(string)
+		if userId == "" {
+			conn.Close()
+			return
+		}
+
+		ch := hub.Subscribe(userId)
+		defer hub.Unsubscribe(userId, ch)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Detect the connection closing so the poll loop below can stop -
+		// ReadMessage blocks until the client disconnects or errors.
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		go pollQuotes(ctx, hub, sync, quotes, userId, interval)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case frame, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := conn.WriteJSON(frame); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// pollQuotes periodically fetches quotes for userId's current holdings and
+// broadcasts them to the hub, until ctx is cancelled. A failed poll backs
+// off exponentially (capped at ~1 minute) instead of hammering the quote
+// provider, then resumes at the normal interval once a poll succeeds.
+func pollQuotes(ctx context.Context, hub *Hub, sync *services.SyncService, quotes QuoteProvider, userId string, interval time.Duration) {
+	backoff := interval
+	const maxBackoff = time.Minute
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		symbols, err := symbolsForUser(ctx, sync, userId)
+		if err != nil {
+			log.Printf("stream: failed to resolve symbols for user %s: %v", userId, err)
+			backoff = nextBackoff(backoff, maxBackoff)
+			continue
+		}
+		if len(symbols) == 0 {
+			backoff = interval
+			continue
+		}
+
+		results, err := quotes.GetQuotes(ctx, symbols)
+		if err != nil {
+			log.Printf("stream: failed to fetch quotes for user %s: %v", userId, err)
+			backoff = nextBackoff(backoff, maxBackoff)
+			continue
+		}
+
+		now := time.Now()
+		for _, q := range results {
+			hub.Broadcast(userId, Frame{Type: "quote", Symbol: q.Symbol, Price: q.Price, Timestamp: now})
+		}
+		backoff = interval
+	}
+}
+
+func nextBackoff(current, max time.Duration) time.Duration {
+	next := current * 2
+	if next > max {
+		return max
+	}
+	return next
+}
+
+// symbolsForUser returns the distinct set of symbols currently held across
+// all of userId's synced accounts.
+func symbolsForUser(ctx context.Context, sync *services.SyncService, userId string) ([]string, error) {
+	accounts, err := sync.CachedAccounts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, account := range accounts {
+		holdings, err := sync.CachedHoldings(ctx, account.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range holdings {
+			if !seen[h.Symbol] {
+				seen[h.Symbol] = true
+				symbols = append(symbols, h.Symbol)
+			}
+		}
+	}
+	return symbols, nil
+}
@@ -0,0 +1,82 @@
+// Package stream pushes real-time position valuations and fills to
+// connected browsers over WebSocket, modeled on how Alpaca's v2 data API
+// streams trades/quotes/bars: a hub fans frames out to per-user
+// subscriber channels, fed by a pluggable QuoteProvider polled on an
+// interval.
+package stream
+
+import (
+	"sync"
+	"time"
+)
+
+// Frame is one JSON message pushed to a subscriber.
+type Frame struct {
+	Type      string    `json:"type"` // "quote" | "fill" | "pnl"
+	Symbol    string    `json:"symbol,omitempty"`
+	Price     float64   `json:"price,omitempty"`
+	Quantity  float64   `json:"quantity,omitempty"`
+	PnL       float64   `json:"pnl,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Quote is a single symbol's latest price, as returned by a QuoteProvider.
+type Quote struct {
+	Symbol string
+	Price  float64
+}
+
+// Hub fans Frames out to the subscriber channels registered for a user.
+// A user may have more than one open connection (e.g. two browser tabs),
+// so each user keys a set of channels rather than a single one.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan Frame]struct{}
+}
+
+// NewHub creates an empty Hub.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[string]map[chan Frame]struct{})}
+}
+
+// Subscribe registers and returns a new channel for userId. The caller
+// must call Unsubscribe with the same channel once it's done reading.
+func (h *Hub) Subscribe(userId string) chan Frame {
+	ch := make(chan Frame, 16)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[userId] == nil {
+		h.subscribers[userId] = make(map[chan Frame]struct{})
+	}
+	h.subscribers[userId][ch] = struct{}{}
+
+	return ch
+}
+
+// Unsubscribe removes ch from userId's subscriber set and closes it.
+func (h *Hub) Unsubscribe(userId string, ch chan Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if subs, ok := h.subscribers[userId]; ok {
+		delete(subs, ch)
+		if len(subs) == 0 {
+			delete(h.subscribers, userId)
+		}
+	}
+	close(ch)
+}
+
+// Broadcast pushes frame to every channel subscribed for userId. A
+// subscriber that isn't keeping up has the frame dropped rather than
+// blocking the whole hub.
+func (h *Hub) Broadcast(userId string, frame Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[userId] {
+		select {
+		case ch <- frame:
+		default:
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"context"
+
+	"snaptrade-service/services"
+)
+
+// QuoteProvider fetches the latest price for a set of symbols. It's the
+// extension seam a real streaming market-data feed slots into later -
+// HoldingsQuoteProvider is the interim implementation, backed by whatever
+// price SyncService last cached from the aggregator.
+type QuoteProvider interface {
+	GetQuotes(ctx context.Context, symbols []string) ([]Quote, error)
+}
+
+// HoldingsQuoteProvider serves quotes from the cached holdings table
+// instead of a live market-data feed. It's accurate only as of the last
+// sync, but requires no additional third-party integration to stand the
+// stream up.
+type HoldingsQuoteProvider struct {
+	sync *services.SyncService
+}
+
+// NewHoldingsQuoteProvider builds a HoldingsQuoteProvider.
+func NewHoldingsQuoteProvider(sync *services.SyncService) *HoldingsQuoteProvider {
+	return &HoldingsQuoteProvider{sync: sync}
+}
+
+// GetQuotes returns the cached price of every symbol in symbols, skipping
+// any symbol that has no cached holding.
+func (p *HoldingsQuoteProvider) GetQuotes(ctx context.Context, symbols []string) ([]Quote, error) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		wanted[s] = true
+	}
+
+	prices, err := p.sync.LatestPrices(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]Quote, 0, len(prices))
+	for symbol, price := range prices {
+		if wanted[symbol] {
+			quotes = append(quotes, Quote{Symbol: symbol, Price: price})
+		}
+	}
+	return quotes, nil
+}
@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"snaptrade-service/provider"
+	"snaptrade-service/services"
+)
+
+// Subscriber is a callback other services can register to react to a
+// SnapTrade webhook event after it has been persisted. Returning an error
+// does not stop the built-in handler's own persistence, but is logged by
+// the caller so other subscribers still run.
+type Subscriber func(ctx context.Context, event Event) error
+
+const webhookEventsTable = "snaptrade_webhook_events"
+
+// Dispatcher deduplicates incoming SnapTrade webhook events, dispatches
+// them to a typed handler per event type, and persists the resulting state
+// changes through DatabaseService.
+type Dispatcher struct {
+	db          *services.DatabaseService
+	sync        *services.SyncService
+	agg         provider.Aggregator
+	subscribers map[EventType][]Subscriber
+}
+
+// NewDispatcher creates a Dispatcher backed by db. ACCOUNT_HOLDINGS_UPDATED
+// and TRANSACTIONS_UPDATED events are re-synced through agg via sync.
+func NewDispatcher(db *services.DatabaseService, sync *services.SyncService, agg provider.Aggregator) *Dispatcher {
+	return &Dispatcher{
+		db:          db,
+		sync:        sync,
+		agg:         agg,
+		subscribers: make(map[EventType][]Subscriber),
+	}
+}
+
+// Subscribe registers a callback invoked after the built-in handler for
+// eventType has run successfully.
+func (d *Dispatcher) Subscribe(eventType EventType, sub Subscriber) {
+	d.subscribers[eventType] = append(d.subscribers[eventType], sub)
+}
+
+// Dispatch deduplicates event.ID, runs the typed handler for event.Type,
+// and notifies any subscribers registered for that type. It returns nil for
+// events that have already been processed so callers can treat replays
+// idempotently.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event, rawPayload string) error {
+	seen, err := d.alreadyProcessed(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("webhook: dedup check failed: %w", err)
+	}
+	if seen {
+		return nil
+	}
+
+	if err := d.record(ctx, event, rawPayload); err != nil {
+		return fmt.Errorf("webhook: failed to record event: %w", err)
+	}
+
+	if err := d.handle(ctx, event); err != nil {
+		return fmt.Errorf("webhook: handler failed for %s: %w", event.Type, err)
+	}
+
+	for _, sub := range d.subscribers[event.Type] {
+		if err := sub(ctx, event); err != nil {
+			fmt.Printf("webhook: subscriber error for event %s (%s): %v\n", event.ID, event.Type, err)
+		}
+	}
+
+	return d.markProcessed(ctx, event.ID)
+}
+
+// Replay re-runs the typed handler and subscribers for an already-recorded
+// event without re-inserting it or failing on the dedup check, so the
+// replay CLI can safely reprocess events that already exist in
+// snaptrade_webhook_events.
+func (d *Dispatcher) Replay(ctx context.Context, event Event, rawPayload string) error {
+	if err := d.handle(ctx, event); err != nil {
+		return fmt.Errorf("webhook: replay handler failed for %s: %w", event.Type, err)
+	}
+
+	for _, sub := range d.subscribers[event.Type] {
+		if err := sub(ctx, event); err != nil {
+			fmt.Printf("webhook: subscriber error during replay of event %s (%s): %v\n", event.ID, event.Type, err)
+		}
+	}
+
+	return d.markProcessed(ctx, event.ID)
+}
+
+func (d *Dispatcher) alreadyProcessed(ctx context.Context, eventID string) (bool, error) {
+	var existing []StoredEvent
+	err := d.db.Select(ctx, webhookEventsTable, "id", map[string]interface{}{"id": eventID}, &existing)
+	if err != nil {
+		return false, err
+	}
+	return len(existing) > 0, nil
+}
+
+func (d *Dispatcher) record(ctx context.Context, event Event, rawPayload string) error {
+	return d.db.Insert(ctx, webhookEventsTable, StoredEvent{
+		ID:         event.ID,
+		EventType:  string(event.Type),
+		Payload:    rawPayload,
+		ReceivedAt: time.Now(),
+	})
+}
+
+func (d *Dispatcher) markProcessed(ctx context.Context, eventID string) error {
+	return d.db.Update(ctx, webhookEventsTable, map[string]interface{}{
+		"processed_at": time.Now(),
+	}, map[string]interface{}{"id": eventID})
+}
+
+// handle applies the state change for a single typed event. Unrecognized
+// event types are a no-op - they're already persisted for dedup/replay.
+func (d *Dispatcher) handle(ctx context.Context, event Event) error {
+	switch event.Type {
+	case EventConnectionBroken:
+		return d.db.Update(ctx, "snaptrade_connections", map[string]interface{}{
+			"status": "broken",
+		}, map[string]interface{}{"connection_id": event.ConnectionID})
+
+	case EventConnectionDeleted:
+		return d.db.Delete(ctx, "snaptrade_connections", map[string]interface{}{
+			"connection_id": event.ConnectionID,
+		})
+
+	case EventConnectionAdded:
+		return d.db.Update(ctx, "snaptrade_connections", map[string]interface{}{
+			"status": "active",
+		}, map[string]interface{}{"connection_id": event.ConnectionID})
+
+	case EventAccountHoldingsUpdate:
+		return d.sync.SyncHoldings(ctx, d.agg, event.UserID, event.UserSecret, event.AccountID)
+
+	case EventTransactionsUpdated:
+		return d.sync.SyncTransactions(ctx, d.agg, event.UserID, event.UserSecret, event.AccountID)
+
+	case EventUserDeleted:
+		return d.db.Delete(ctx, "snaptrade_users", map[string]interface{}{
+			"user_id": event.UserID,
+		})
+
+	case EventUserRegistered:
+		// Nothing to reconcile here: the user row is created synchronously
+		// by CreateSnapTradeUser when the registration call succeeds.
+		return nil
+
+	default:
+		return nil
+	}
+}
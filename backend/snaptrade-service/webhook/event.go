@@ -0,0 +1,39 @@
+package webhook
+
+import "time"
+
+// EventType enumerates the asynchronous SnapTrade webhook events this
+// service knows how to handle. SnapTrade may send other event types in the
+// future; unrecognized types are persisted for dedup but otherwise ignored.
+type EventType string
+
+const (
+	EventUserRegistered        EventType = "USER_REGISTERED"
+	EventUserDeleted           EventType = "USER_DELETED"
+	EventConnectionAdded       EventType = "CONNECTION_ADDED"
+	EventConnectionBroken      EventType = "CONNECTION_BROKEN"
+	EventConnectionDeleted     EventType = "CONNECTION_DELETED"
+	EventAccountHoldingsUpdate EventType = "ACCOUNT_HOLDINGS_UPDATED"
+	EventTransactionsUpdated   EventType = "TRANSACTIONS_UPDATED"
+)
+
+// Event is the normalized shape of a SnapTrade webhook payload.
+type Event struct {
+	ID           string    `json:"id"`
+	Type         EventType `json:"eventType"`
+	UserID       string    `json:"userId"`
+	UserSecret   string    `json:"userSecret,omitempty"`
+	ConnectionID string    `json:"brokerageAuthorizationId,omitempty"`
+	AccountID    string    `json:"accountId,omitempty"`
+	Timestamp    time.Time `json:"eventTimestamp"`
+}
+
+// StoredEvent is the row persisted to the snaptrade_webhook_events table,
+// used both for dedup and as the source of truth for the replay CLI.
+type StoredEvent struct {
+	ID          string    `json:"id"`
+	EventType   string    `json:"event_type"`
+	Payload     string    `json:"payload"`
+	ReceivedAt  time.Time `json:"received_at"`
+	ProcessedAt time.Time `json:"processed_at,omitempty"`
+}
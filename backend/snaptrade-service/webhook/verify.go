@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// VerifySignature checks an HMAC-SHA256 signature of body against
+// SNAPTRADE_WEBHOOK_SECRET, using a constant-time comparison.
+func VerifySignature(body []byte, signature string) error {
+	secret := os.Getenv("SNAPTRADE_WEBHOOK_SECRET")
+	if secret == "" {
+		return fmt.Errorf("SNAPTRADE_WEBHOOK_SECRET must be set")
+	}
+	if signature == "" {
+		return fmt.Errorf("missing webhook signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}